@@ -7,13 +7,20 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"syscall"
 
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/auth"
 	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/broker"
 	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/config"
 	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/handler"
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/middleware"
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/ports"
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/ratelimit"
 	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/repository"
 	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/service"
+	"github.com/dev-bruno-arruda/api-pedidos/rabbitmq"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func main() {
@@ -37,12 +44,15 @@ func main() {
 	log.Println("Conectado ao MongoDB")
 
 	publisher, err := broker.NewRabbitMQPublisher(broker.PublisherConfig{
-		URI:          cfg.RabbitMQ.URI,
-		QueueName:    cfg.RabbitMQ.QueueName,
-		ExchangeName: cfg.RabbitMQ.ExchangeName,
-		MaxRetries:   cfg.RabbitMQ.MaxRetries,
-		RetryDelay:   cfg.RabbitMQ.RetryDelay,
-		Timeout:      cfg.RabbitMQ.PublishTimeout,
+		URI:           cfg.RabbitMQ.URI,
+		QueueName:     cfg.RabbitMQ.QueueName,
+		ExchangeName:  cfg.RabbitMQ.ExchangeName,
+		MaxRetries:    cfg.RabbitMQ.MaxRetries,
+		RetryDelay:    cfg.RabbitMQ.RetryDelay,
+		MaxRetryDelay: cfg.RabbitMQ.MaxRetryDelay,
+		Timeout:       cfg.RabbitMQ.PublishTimeout,
+		ConfirmMode:   cfg.RabbitMQ.ConfirmMode,
+		Mandatory:     cfg.RabbitMQ.Mandatory,
 	})
 	if err != nil {
 		log.Fatalf("Erro ao conectar ao RabbitMQ: %v", err)
@@ -50,12 +60,59 @@ func main() {
 	log.Println("Conectado ao RabbitMQ")
 
 	orderRepo := repository.NewOrderRepository(mongoClient, cfg.MongoDB.Database, cfg.MongoDB.Collection)
-	orderService := service.NewOrderService(orderRepo, publisher)
+	if err := orderRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Erro ao criar índices no MongoDB: %v", err)
+	}
+
+	customerRepo := repository.NewCustomerRepository(mongoClient, cfg.MongoDB.Database, cfg.MongoDB.CustomersCollection)
+	if err := customerRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Erro ao criar índices no MongoDB: %v", err)
+	}
+
+	apiKeyRepo := repository.NewAPIKeyRepository(mongoClient, cfg.MongoDB.Database, cfg.MongoDB.APIKeysCollection)
+	if err := apiKeyRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Erro ao criar índices no MongoDB: %v", err)
+	}
+
+	outboxRepo := repository.NewOutboxRepository(mongoClient, cfg.MongoDB.Database, cfg.Outbox.Collection)
+	if err := outboxRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Erro ao criar índices no MongoDB: %v", err)
+	}
+
+	idempotencyRepo := repository.NewIdempotencyRepository(mongoClient, cfg.MongoDB.Database, cfg.Idempotency.Collection)
+	if err := idempotencyRepo.EnsureIndexes(context.Background(), cfg.Idempotency.TTL); err != nil {
+		log.Fatalf("Erro ao criar índices no MongoDB: %v", err)
+	}
+
+	orderService := service.NewOrderService(orderRepo, customerRepo, outboxRepo, idempotencyRepo, repository.NewMongoTransactor(mongoClient), service.OrderServiceConfig{
+		InstanceID:             cfg.Server.InstanceID,
+		IdempotencyLockTimeout: cfg.Idempotency.LockTimeout,
+		TaxRateBps:             cfg.Pricing.TaxRateBps,
+	})
+
+	outboxRelay := service.NewOutboxRelay(outboxRepo, publisher, service.OutboxRelayConfig{
+		Workers:        cfg.Outbox.Workers,
+		PollInterval:   cfg.Outbox.PollInterval,
+		LeaseTimeout:   cfg.Outbox.LeaseTimeout,
+		RetryBaseDelay: cfg.Outbox.RetryBaseDelay,
+		RetryMaxDelay:  cfg.Outbox.RetryMaxDelay,
+	})
+	outboxRelay.Start()
+
 	orderHandler := handler.NewOrderHandler(orderService)
+	customerHandler := handler.NewCustomerHandler(service.NewCustomerService(customerRepo))
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
 
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		if state := publisher.State(); state != rabbitmq.StateConnected {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintf(w, "unhealthy: rabbitmq %s", state)
+			log.Printf("Health check: RabbitMQ %s", state)
+			return
+		}
 		w.WriteHeader(http.StatusOK)
 		fmt.Fprintf(w, "healthy")
 		log.Println("Health check: OK")
@@ -69,14 +126,78 @@ func main() {
 		w.WriteHeader(http.StatusOK)
 		fmt.Fprintf(w, "API Service OK\n")
 		fmt.Fprintf(w, "POST /orders - Criar novo pedido\n")
+		fmt.Fprintf(w, "GET /orders - Listar pedidos\n")
+		fmt.Fprintf(w, "GET /orders/{order_id} - Consultar pedido\n")
+		fmt.Fprintf(w, "GET /orders/{order_id}/history - Histórico de status do pedido\n")
+		fmt.Fprintf(w, "POST /orders/{order_id}/cancel - Cancelar pedido\n")
+		fmt.Fprintf(w, "POST /customers - Criar cliente\n")
+		fmt.Fprintf(w, "GET /customers/{customer_id} - Consultar cliente\n")
+		fmt.Fprintf(w, "GET /customers/{customer_id}/orders - Listar pedidos do cliente\n")
+		fmt.Fprintf(w, "POST /api-keys - Criar chave de API\n")
+		fmt.Fprintf(w, "GET /api-keys - Listar chaves de API\n")
+		fmt.Fprintf(w, "POST /api-keys/{key_id}/revoke - Revogar chave de API\n")
 		fmt.Fprintf(w, "GET /health - Health check\n")
 	})
 
-	mux.HandleFunc("/orders", orderHandler.CreateOrder)
+	routes := []struct {
+		pattern string
+		scope   string
+		handler middleware.HandlerFunc
+	}{
+		{"POST /orders", auth.ScopeOrdersWrite, orderHandler.CreateOrder},
+		{"GET /orders", auth.ScopeOrdersRead, orderHandler.ListOrders},
+		{"GET /orders/{order_id}", auth.ScopeOrdersRead, orderHandler.GetOrder},
+		{"GET /orders/{order_id}/history", auth.ScopeOrdersRead, orderHandler.GetOrderHistory},
+		{"POST /orders/{order_id}/cancel", auth.ScopeOrdersAdmin, orderHandler.CancelOrder},
+		{"POST /customers", auth.ScopeCustomersWrite, customerHandler.CreateCustomer},
+		{"GET /customers/{customer_id}", auth.ScopeCustomersRead, customerHandler.GetCustomer},
+		{"GET /customers/{customer_id}/orders", auth.ScopeOrdersRead, orderHandler.ListCustomerOrders},
+		{"POST /api-keys", auth.ScopeAPIKeysAdmin, apiKeyHandler.CreateAPIKey},
+		{"GET /api-keys", auth.ScopeAPIKeysAdmin, apiKeyHandler.ListAPIKeys},
+		{"POST /api-keys/{key_id}/revoke", auth.ScopeAPIKeysAdmin, apiKeyHandler.RevokeAPIKey},
+	}
+
+	patterns := make([]string, 0, len(routes))
+	for _, route := range routes {
+		patterns = append(patterns, route.pattern)
+	}
+	rateLimits := newRateLimits(cfg, mongoClient, patterns)
+
+	for _, route := range routes {
+		h := middleware.RequireScope(route.scope, route.handler)
+		if rateLimits != nil {
+			h = rateLimits.Wrap(route.pattern, h)
+		}
+		mux.HandleFunc(route.pattern, middleware.HandleErrors(h))
+	}
+
+	var rootHandler http.Handler = mux
+	if cfg.Auth.Enabled {
+		verifier, err := auth.NewJWTVerifier(auth.JWTConfig{
+			HS256Secret: cfg.Auth.HS256Secret,
+			JWKSFile:    cfg.Auth.JWKSFile,
+			Issuer:      cfg.Auth.Issuer,
+			Audience:    cfg.Auth.Audience,
+			Leeway:      cfg.Auth.Leeway,
+		})
+		if err != nil {
+			log.Fatalf("Erro ao configurar autenticação JWT: %v", err)
+		}
+		authenticators := []auth.Authenticator{verifier, auth.NewAPIKeyAuthenticator(apiKeyService)}
+		rootHandler = middleware.Authenticate(authenticators, "/health")(mux)
+		log.Println("Autenticação habilitada (JWT e chaves de API)")
+	} else {
+		log.Println("ATENÇÃO: autenticação desabilitada (AUTH_ENABLED=false)")
+	}
+	// O limite por IP fica antes da autenticação para conter também as
+	// requisições rejeitadas com 401.
+	if rateLimits != nil {
+		rootHandler = rateLimits.LimitByIP("/health")(rootHandler)
+	}
 
 	server := &http.Server{
 		Addr:         ":" + cfg.Server.Port,
-		Handler:      mux,
+		Handler:      rootHandler,
 		ReadTimeout:  cfg.Server.ReadTimeout,
 		WriteTimeout: cfg.Server.WriteTimeout,
 		IdleTimeout:  cfg.Server.IdleTimeout,
@@ -106,8 +227,8 @@ func main() {
 	cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), cfg.Shutdown.CleanupTimeout)
 	defer cleanupCancel()
 
-	log.Println("Encerrando workers do OrderService...")
-	orderService.Shutdown()
+	log.Println("Encerrando workers do outbox...")
+	outboxRelay.Shutdown()
 
 	if err := publisher.Close(); err != nil {
 		log.Printf("Erro ao fechar conexão com RabbitMQ: %v", err)
@@ -123,3 +244,48 @@ func main() {
 
 	log.Println("Shutdown completo")
 }
+
+// newRateLimits monta o rate limiting configurado, ou nil se estiver desabilitado.
+// patterns são as rotas registradas; um limite em RATE_LIMIT_ROUTES para outra
+// rota (ex.: com erro de digitação) impede a inicialização.
+func newRateLimits(cfg *config.Config, mongoClient *mongo.Client, patterns []string) *middleware.RateLimits {
+	if !cfg.RateLimit.Enabled {
+		log.Println("ATENÇÃO: rate limiting desabilitado (RATE_LIMIT_ENABLED=false)")
+		return nil
+	}
+
+	defaultLimit, err := ratelimit.ParseLimit(cfg.RateLimit.Default)
+	if err != nil {
+		log.Fatalf("RATE_LIMIT_DEFAULT: %v", err)
+	}
+	routes, err := ratelimit.ParseRoutes(cfg.RateLimit.Routes)
+	if err != nil {
+		log.Fatalf("RATE_LIMIT_ROUTES: %v", err)
+	}
+	for route := range routes {
+		if !slices.Contains(patterns, route) {
+			log.Fatalf("RATE_LIMIT_ROUTES: rota %q não registrada na API (use o padrão exato, ex.: \"GET /orders/{order_id}\")", route)
+		}
+	}
+	perIP, err := ratelimit.ParseLimit(cfg.RateLimit.PerIP)
+	if err != nil {
+		log.Fatalf("RATE_LIMIT_PER_IP: %v", err)
+	}
+
+	var limiter ports.RateLimiter
+	switch cfg.RateLimit.Backend {
+	case "memory":
+		limiter = ratelimit.NewMemoryLimiter()
+	case "mongo":
+		mongoLimiter := ratelimit.NewMongoLimiter(mongoClient, cfg.MongoDB.Database, cfg.RateLimit.Collection)
+		if err := mongoLimiter.EnsureIndexes(context.Background()); err != nil {
+			log.Fatalf("Erro ao criar índices no MongoDB: %v", err)
+		}
+		limiter = mongoLimiter
+	default:
+		log.Fatalf("RATE_LIMIT_BACKEND inválido: %q (use memory ou mongo)", cfg.RateLimit.Backend)
+	}
+
+	log.Printf("Rate limiting habilitado (backend %s, padrão %s, por IP %s)", cfg.RateLimit.Backend, cfg.RateLimit.Default, cfg.RateLimit.PerIP)
+	return middleware.NewRateLimits(limiter, defaultLimit, routes, perIP, cfg.RateLimit.TrustForwardedFor)
+}
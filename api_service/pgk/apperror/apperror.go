@@ -0,0 +1,96 @@
+package apperror
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kind classifica o erro independentemente da mensagem; a camada HTTP escolhe
+// o status a partir dele.
+type Kind string
+
+const (
+	KindUnauthenticated Kind = "unauthenticated"
+	KindForbidden       Kind = "forbidden"
+	KindNotFound        Kind = "not_found"
+	KindConflict        Kind = "conflict"
+	KindValidation      Kind = "validation"
+	KindUnprocessable   Kind = "unprocessable"
+	KindRateLimited     Kind = "rate_limited"
+	KindUnavailable     Kind = "unavailable"
+	KindInternal        Kind = "internal"
+)
+
+// Error é um erro tipado com um código estável (Code) e uma mensagem que pode
+// ser exibida ao cliente (Message). Err guarda a causa, que não é exposta.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func NotFound(code, message string) *Error {
+	return New(KindNotFound, code, message)
+}
+
+func Conflict(code, message string) *Error {
+	return New(KindConflict, code, message)
+}
+
+func Validation(code, message string) *Error {
+	return New(KindValidation, code, message)
+}
+
+func Unprocessable(code, message string) *Error {
+	return New(KindUnprocessable, code, message)
+}
+
+func Unauthenticated(code, message string) *Error {
+	return New(KindUnauthenticated, code, message)
+}
+
+func Forbidden(code, message string) *Error {
+	return New(KindForbidden, code, message)
+}
+
+func RateLimited(code, message string) *Error {
+	return New(KindRateLimited, code, message)
+}
+
+// Unavailable indica falha de uma dependência (MongoDB, RabbitMQ) que pode se
+// resolver sozinha; o cliente pode tentar novamente.
+func Unavailable(code, message string, err error) *Error {
+	return &Error{Kind: KindUnavailable, Code: code, Message: message, Err: err}
+}
+
+// As retorna o primeiro *Error na cadeia de err.
+func As(err error) (*Error, bool) {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr, true
+	}
+	return nil, false
+}
+
+func KindOf(err error) Kind {
+	if appErr, ok := As(err); ok {
+		return appErr.Kind
+	}
+	return KindInternal
+}
@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/apperror"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+var ErrInvalidAPIKey = apperror.Unauthenticated("invalid_api_key", "Chave de API inválida, expirada ou revogada")
+
+// Authenticator identifica o chamador por um tipo de credencial. Requisições
+// sem essa credencial retornam ErrMissingCredentials, para que o próximo
+// Authenticator seja tentado.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// APIKeyVerifier valida uma chave de API e devolve o chamador dono dela.
+type APIKeyVerifier interface {
+	VerifyAPIKey(ctx context.Context, key string) (*Principal, error)
+}
+
+// APIKeyAuthenticator autentica pelo header X-API-Key.
+type APIKeyAuthenticator struct {
+	verifier APIKeyVerifier
+}
+
+func NewAPIKeyAuthenticator(verifier APIKeyVerifier) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{verifier: verifier}
+}
+
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	key := r.Header.Get(APIKeyHeader)
+	if key == "" {
+		return nil, ErrMissingCredentials
+	}
+	return a.verifier.VerifyAPIKey(r.Context(), key)
+}
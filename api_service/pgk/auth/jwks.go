@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey é uma chave do JWKS com o algoritmo que ela pode verificar.
+type publicKey struct {
+	kid string
+	alg string
+	key crypto.PublicKey
+}
+
+// loadJWKS lê as chaves públicas RSA (RS256) e EC P-256 (ES256) de um arquivo
+// JWKS. Chaves de outros tipos ou marcadas para outro uso são ignoradas.
+func loadJWKS(path string) ([]publicKey, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler JWKS %s: %w", path, err)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(raw, &set); err != nil {
+		return nil, fmt.Errorf("erro ao decodificar JWKS %s: %w", path, err)
+	}
+
+	var keys []publicKey
+	for i, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		var key publicKey
+		switch {
+		case k.Kty == "RSA" && (k.Alg == "" || k.Alg == "RS256"):
+			key, err = rsaKey(k)
+		case k.Kty == "EC" && k.Crv == "P-256" && (k.Alg == "" || k.Alg == "ES256"):
+			key, err = ecKey(k)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("chave %d (kid %q) do JWKS inválida: %w", i, k.Kid, err)
+		}
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWKS %s não tem chaves RS256 ou ES256", path)
+	}
+	return keys, nil
+}
+
+func rsaKey(k jwk) (publicKey, error) {
+	n, err := decodeBigInt(k.N)
+	if err != nil {
+		return publicKey{}, fmt.Errorf("n: %w", err)
+	}
+	e, err := decodeBigInt(k.E)
+	if err != nil {
+		return publicKey{}, fmt.Errorf("e: %w", err)
+	}
+	if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+		return publicKey{}, fmt.Errorf("expoente inválido")
+	}
+	return publicKey{kid: k.Kid, alg: "RS256", key: &rsa.PublicKey{N: n, E: int(e.Int64())}}, nil
+}
+
+func ecKey(k jwk) (publicKey, error) {
+	x, err := decodeBigInt(k.X)
+	if err != nil {
+		return publicKey{}, fmt.Errorf("x: %w", err)
+	}
+	y, err := decodeBigInt(k.Y)
+	if err != nil {
+		return publicKey{}, fmt.Errorf("y: %w", err)
+	}
+	curve := elliptic.P256()
+	if !curve.IsOnCurve(x, y) {
+		return publicKey{}, fmt.Errorf("ponto fora da curva P-256")
+	}
+	return publicKey{kid: k.Kid, alg: "ES256", key: &ecdsa.PublicKey{Curve: curve, X: x, Y: y}}, nil
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	if value == "" {
+		return nil, fmt.Errorf("campo obrigatório")
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/apperror"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrMissingCredentials = apperror.Unauthenticated("missing_credentials", "Credenciais de acesso ausentes")
+	ErrInvalidToken       = apperror.Unauthenticated("invalid_token", "Token de acesso inválido ou expirado")
+)
+
+type JWTConfig struct {
+	// HS256Secret habilita tokens HS256 assinados com o segredo compartilhado.
+	HS256Secret string
+	// JWKSFile habilita tokens RS256/ES256 verificados pelas chaves do arquivo.
+	JWKSFile string
+	Issuer   string
+	Audience string
+	Leeway   time.Duration
+}
+
+// JWTVerifier valida tokens de acesso: assinatura, exp/nbf (com Leeway), iss e
+// aud. O algoritmo aceito é definido pela chave, nunca apenas pelo header do
+// token.
+type JWTVerifier struct {
+	secret     []byte
+	publicKeys []publicKey
+	parser     *jwt.Parser
+}
+
+func NewJWTVerifier(config JWTConfig) (*JWTVerifier, error) {
+	v := &JWTVerifier{secret: []byte(config.HS256Secret)}
+
+	var methods []string
+	if config.HS256Secret != "" {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if config.JWKSFile != "" {
+		keys, err := loadJWKS(config.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		v.publicKeys = keys
+		methods = append(methods, jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg())
+	}
+	if len(methods) == 0 {
+		return nil, errors.New("configure AUTH_JWT_HS256_SECRET ou AUTH_JWT_JWKS_FILE")
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(config.Leeway),
+	}
+	if config.Issuer != "" {
+		options = append(options, jwt.WithIssuer(config.Issuer))
+	}
+	if config.Audience != "" {
+		options = append(options, jwt.WithAudience(config.Audience))
+	}
+	v.parser = jwt.NewParser(options...)
+
+	return v, nil
+}
+
+type accessClaims struct {
+	jwt.RegisteredClaims
+	// Scope segue o RFC 8693 (escopos separados por espaço); Scopes aceita a
+	// forma de lista usada por alguns provedores.
+	Scope      string   `json:"scope,omitempty"`
+	Scopes     []string `json:"scopes,omitempty"`
+	CustomerID string   `json:"customer_id,omitempty"`
+}
+
+// Authenticate valida o Bearer token do header Authorization.
+func (v *JWTVerifier) Authenticate(r *http.Request) (*Principal, error) {
+	token, ok := bearerToken(r)
+	if !ok {
+		return nil, ErrMissingCredentials
+	}
+	return v.Verify(token)
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+func (v *JWTVerifier) Verify(token string) (*Principal, error) {
+	var claims accessClaims
+	_, err := v.parser.ParseWithClaims(token, &claims, v.key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: token sem sub", ErrInvalidToken)
+	}
+
+	scopes := append(strings.Fields(claims.Scope), claims.Scopes...)
+	return &Principal{Subject: claims.Subject, Scopes: scopes, CustomerID: claims.CustomerID, Method: MethodJWT}, nil
+}
+
+func (v *JWTVerifier) key(token *jwt.Token) (any, error) {
+	alg := token.Method.Alg()
+	if alg == jwt.SigningMethodHS256.Alg() {
+		return v.secret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	var candidates []publicKey
+	for _, key := range v.publicKeys {
+		if key.alg != alg {
+			continue
+		}
+		if kid != "" && key.kid == kid {
+			return key.key, nil
+		}
+		candidates = append(candidates, key)
+	}
+
+	// Sem kid, a chave só é escolhida se não houver ambiguidade.
+	if kid == "" && len(candidates) == 1 {
+		return candidates[0].key, nil
+	}
+	return nil, fmt.Errorf("nenhuma chave %s com kid %q", alg, kid)
+}
@@ -0,0 +1,32 @@
+package auth
+
+import "context"
+
+const (
+	MethodJWT    = "jwt"
+	MethodAPIKey = "api_key"
+)
+
+// Principal é o chamador autenticado da requisição.
+type Principal struct {
+	Subject string
+	Scopes  []string
+	// CustomerID vincula o chamador a um cliente; vazio para chamadores que não
+	// são clientes (ex.: sistemas internos).
+	CustomerID string
+	// Method indica como o chamador se autenticou (MethodJWT ou MethodAPIKey).
+	Method string
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFrom retorna o chamador autenticado, ou false em rotas públicas e
+// com a autenticação desabilitada.
+func PrincipalFrom(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
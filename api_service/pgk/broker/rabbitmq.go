@@ -3,77 +3,104 @@ package broker
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/models"
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/ports"
+	"github.com/dev-bruno-arruda/api-pedidos/rabbitmq"
+	"github.com/google/uuid"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
 type RabbitMQPublisher struct {
-	conn         *amqp.Connection
-	channel      *amqp.Channel
+	connection   *rabbitmq.ConnectionManager
 	queueName    string
 	exchangeName string
 	timeout      time.Duration
+	confirmMode  bool
+	mandatory    bool
+	publishMu    sync.Mutex
+
+	returnsMu sync.Mutex
+	returns   chan amqp.Return
 }
 
 type PublisherConfig struct {
-	URI          string
-	QueueName    string
-	ExchangeName string
-	MaxRetries   int
-	RetryDelay   time.Duration
-	Timeout      time.Duration
+	URI           string
+	QueueName     string
+	ExchangeName  string
+	MaxRetries    int
+	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
+	Timeout       time.Duration
+	// ConfirmMode coloca o canal em modo de confirmação: a publicação só é
+	// considerada bem-sucedida depois do ack do broker.
+	ConfirmMode bool
+	// Mandatory faz o broker devolver mensagens que não puderam ser roteadas
+	// para nenhuma fila. Com ConfirmMode a devolução é retornada como erro.
+	Mandatory bool
 }
 
 func NewRabbitMQPublisher(config PublisherConfig) (*RabbitMQPublisher, error) {
-	var conn *amqp.Connection
-	var err error
-
-	for i := 0; i < config.MaxRetries; i++ {
-		conn, err = amqp.Dial(config.URI)
-		if err == nil {
-			break
-		}
-		log.Printf("Tentativa %d de %d: Falha ao conectar ao RabbitMQ: %v", i+1, config.MaxRetries, err)
-		time.Sleep(config.RetryDelay)
+	publisher := &RabbitMQPublisher{
+		queueName:    config.QueueName,
+		exchangeName: config.ExchangeName,
+		timeout:      config.Timeout,
+		confirmMode:  config.ConfirmMode,
+		mandatory:    config.Mandatory,
 	}
 
+	connection, err := rabbitmq.NewConnectionManager(rabbitmq.ConnectionConfig{
+		URI:           config.URI,
+		MaxRetries:    config.MaxRetries,
+		RetryDelay:    config.RetryDelay,
+		MaxRetryDelay: config.MaxRetryDelay,
+	}, publisher.setupChannel)
 	if err != nil {
-		return nil, fmt.Errorf("falha ao conectar ao RabbitMQ após %d tentativas: %w", config.MaxRetries, err)
+		return nil, err
 	}
+	publisher.connection = connection
 
-	channel, err := conn.Channel()
-	if err != nil {
-		conn.Close()
-		return nil, fmt.Errorf("falha ao abrir canal: %w", err)
-	}
-
-	_, err = channel.QueueDeclare(
-		config.QueueName,
-		true,  // durable
-		false, // delete when unused
-		false, // exclusive
-		false, // no-wait
-		nil,   // arguments
-	)
-	if err != nil {
-		channel.Close()
-		conn.Close()
-		return nil, fmt.Errorf("falha ao declarar fila: %w", err)
+	log.Println("Conectado ao RabbitMQ com sucesso")
+
+	return publisher, nil
+}
+
+// setupChannel é executado pelo ConnectionManager a cada (re)conexão.
+func (r *RabbitMQPublisher) setupChannel(channel *amqp.Channel) error {
+	if err := declareOrderQueue(channel, r.queueName); err != nil {
+		return err
 	}
 
-	log.Println("Conectado ao RabbitMQ com sucesso")
+	if r.confirmMode {
+		if err := channel.Confirm(false); err != nil {
+			return fmt.Errorf("falha ao ativar modo de confirmação: %w", err)
+		}
+	}
 
-	return &RabbitMQPublisher{
-		conn:         conn,
-		channel:      channel,
-		queueName:    config.QueueName,
-		exchangeName: config.ExchangeName,
-		timeout:      config.Timeout,
-	}, nil
+	if r.mandatory {
+		// O canal precisa ser bufferizado: o cliente AMQP entrega o basic.return
+		// antes de processar o ack da mesma mensagem.
+		returns := channel.NotifyReturn(make(chan amqp.Return, 16))
+
+		r.returnsMu.Lock()
+		r.returns = returns
+		r.returnsMu.Unlock()
+
+		if !r.confirmMode {
+			go logReturns(returns)
+		}
+	}
+
+	return nil
+}
+
+func (r *RabbitMQPublisher) State() rabbitmq.ConnectionState {
+	return r.connection.State()
 }
 
 func (r *RabbitMQPublisher) PublishOrderMessage(ctx context.Context, message models.OrderMessage) error {
@@ -83,6 +110,10 @@ func (r *RabbitMQPublisher) PublishOrderMessage(ctx context.Context, message mod
 	default:
 	}
 
+	if message.MessageID == "" {
+		message.MessageID = uuid.New().String()
+	}
+
 	body, err := json.Marshal(message)
 	if err != nil {
 		return fmt.Errorf("erro ao serializar mensagem: %w", err)
@@ -91,22 +122,31 @@ func (r *RabbitMQPublisher) PublishOrderMessage(ctx context.Context, message mod
 	publishCtx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
-	err = r.channel.PublishWithContext(
-		publishCtx,
-		r.exchangeName,
-		r.queueName,
-		false,
-		false,
-		amqp.Publishing{
-			ContentType:  "application/json",
-			Body:         body,
-			DeliveryMode: amqp.Persistent,
-		},
-	)
+	publishing := amqp.Publishing{
+		ContentType:  "application/json",
+		MessageId:    message.MessageID,
+		Body:         body,
+		DeliveryMode: amqp.Persistent,
+	}
+
+	channel, err := r.connection.Channel()
+	if err != nil {
+		return fmt.Errorf("erro ao publicar mensagem: %w", err)
+	}
+
+	if r.confirmMode {
+		err = r.publishWithConfirm(ctx, publishCtx, channel, message.MessageID, publishing)
+	} else {
+		err = channel.PublishWithContext(publishCtx, r.exchangeName, r.queueName, r.mandatory, false, publishing)
+	}
 	if err != nil {
 		if ctx.Err() != nil {
 			return fmt.Errorf("contexto cancelado durante publicação: %w", ctx.Err())
 		}
+		var publishErr *ports.PublishError
+		if errors.As(err, &publishErr) {
+			return err
+		}
 		return fmt.Errorf("erro ao publicar mensagem: %w", err)
 	}
 
@@ -114,16 +154,74 @@ func (r *RabbitMQPublisher) PublishOrderMessage(ctx context.Context, message mod
 	return nil
 }
 
-func (r *RabbitMQPublisher) Close() error {
-	if r.channel != nil {
-		if err := r.channel.Close(); err != nil {
+func (r *RabbitMQPublisher) publishWithConfirm(ctx, publishCtx context.Context, channel *amqp.Channel, messageID string, publishing amqp.Publishing) error {
+	if r.mandatory {
+		// Com mandatory as publicações são serializadas para que um basic.return
+		// recebido antes do ack pertença com certeza a esta mensagem.
+		r.publishMu.Lock()
+		defer r.publishMu.Unlock()
+	}
+
+	confirmation, err := channel.PublishWithDeferredConfirmWithContext(publishCtx, r.exchangeName, r.queueName, r.mandatory, false, publishing)
+	if err != nil {
+		return err
+	}
+
+	acked, err := confirmation.WaitContext(publishCtx)
+	if err != nil {
+		if ctx.Err() != nil {
 			return err
 		}
+		return &ports.PublishError{Err: ports.ErrConfirmTimeout, MessageID: messageID}
 	}
-	if r.conn != nil {
-		if err := r.conn.Close(); err != nil {
-			return err
+
+	if r.mandatory {
+		if returned, ok := r.takeReturn(messageID); ok {
+			return &ports.PublishError{
+				Err:       ports.ErrMessageReturned,
+				MessageID: messageID,
+				ReplyCode: returned.ReplyCode,
+				ReplyText: returned.ReplyText,
+			}
 		}
 	}
+
+	if !acked {
+		return &ports.PublishError{Err: ports.ErrMessageNacked, MessageID: messageID}
+	}
+
 	return nil
 }
+
+func (r *RabbitMQPublisher) takeReturn(messageID string) (amqp.Return, bool) {
+	r.returnsMu.Lock()
+	returns := r.returns
+	r.returnsMu.Unlock()
+
+	for {
+		select {
+		case returned, ok := <-returns:
+			if !ok {
+				return amqp.Return{}, false
+			}
+			if returned.MessageId == messageID {
+				return returned, true
+			}
+			log.Printf("Descartando mensagem devolvida de publicação anterior: MessageID=%s, %d %s",
+				returned.MessageId, returned.ReplyCode, returned.ReplyText)
+		default:
+			return amqp.Return{}, false
+		}
+	}
+}
+
+func logReturns(returns <-chan amqp.Return) {
+	for returned := range returns {
+		log.Printf("Mensagem devolvida pelo broker: MessageID=%s, %d %s",
+			returned.MessageId, returned.ReplyCode, returned.ReplyText)
+	}
+}
+
+func (r *RabbitMQPublisher) Close() error {
+	return r.connection.Close()
+}
@@ -0,0 +1,63 @@
+package broker
+
+import (
+	"fmt"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func DeadLetterExchangeName(queueName string) string {
+	return queueName + ".dlx"
+}
+
+func DeadLetterQueueName(queueName string) string {
+	return queueName + ".dlq"
+}
+
+// declareOrderQueue declara a fila principal com dead-letter exchange e a DLQ
+// ligada a ela. O worker declara a mesma topologia, e os argumentos precisam
+// ser idênticos nos dois serviços.
+func declareOrderQueue(channel *amqp.Channel, queueName string) error {
+	dlx := DeadLetterExchangeName(queueName)
+	dlq := DeadLetterQueueName(queueName)
+
+	err := channel.ExchangeDeclare(
+		dlx,
+		amqp.ExchangeDirect,
+		true,  // durable
+		false, // auto-deleted
+		false, // internal
+		false, // no-wait
+		nil,   // arguments
+	)
+	if err != nil {
+		return fmt.Errorf("falha ao declarar dead-letter exchange: %w", err)
+	}
+
+	_, err = channel.QueueDeclare(dlq, true, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("falha ao declarar DLQ: %w", err)
+	}
+
+	err = channel.QueueBind(dlq, queueName, dlx, false, nil)
+	if err != nil {
+		return fmt.Errorf("falha ao vincular DLQ: %w", err)
+	}
+
+	_, err = channel.QueueDeclare(
+		queueName,
+		true,  // durable
+		false, // delete when unused
+		false, // exclusive
+		false, // no-wait
+		amqp.Table{
+			"x-dead-letter-exchange":    dlx,
+			"x-dead-letter-routing-key": queueName,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("falha ao declarar fila: %w", err)
+	}
+
+	return nil
+}
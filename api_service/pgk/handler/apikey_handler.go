@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/models"
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/service"
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/validation"
+)
+
+type APIKeyHandler struct {
+	service *service.APIKeyService
+}
+
+func NewAPIKeyHandler(service *service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		service: service,
+	}
+}
+
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) error {
+	var req models.CreateAPIKeyRequest
+	if err := validation.DecodeJSON(w, r, &req, maxRequestBodyBytes); err != nil {
+		return err
+	}
+
+	if err := validateCreateAPIKeyRequest(req); err != nil {
+		return err
+	}
+
+	response, err := h.service.CreateAPIKey(r.Context(), req)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Chave de API criada com sucesso: %s", response.KeyID)
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusCreated, response)
+	return nil
+}
+
+func (h *APIKeyHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) error {
+	response, err := h.service.ListAPIKeys(r.Context())
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, http.StatusOK, response)
+	return nil
+}
+
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) error {
+	apiKey, err := h.service.RevokeAPIKey(r.Context(), r.PathValue("key_id"))
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Chave de API revogada com sucesso: %s", apiKey.KeyID)
+
+	writeJSON(w, http.StatusOK, apiKey)
+	return nil
+}
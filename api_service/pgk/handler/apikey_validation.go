@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/auth"
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/models"
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/validation"
+)
+
+const maxAPIKeyNameLength = 100
+
+func validateCreateAPIKeyRequest(req models.CreateAPIKeyRequest) error {
+	var v validation.Validator
+
+	name := strings.TrimSpace(req.Name)
+	if v.Required(validation.Pointer("name"), name) && v.Length(validation.Pointer("name"), name, 1, maxAPIKeyNameLength) {
+		v.Printable(validation.Pointer("name"), name)
+	}
+
+	if len(req.Scopes) == 0 {
+		v.Add(validation.Pointer("scopes"), validation.CodeRequired, "a chave deve ter ao menos um escopo")
+	}
+	for i, scope := range req.Scopes {
+		v.Check(slices.Contains(auth.Scopes, scope), validation.Pointer("scopes", i), validation.CodeInvalidValue,
+			fmt.Sprintf("escopo desconhecido: deve ser um de %s", strings.Join(auth.Scopes, ", ")))
+	}
+
+	if req.CustomerID != "" {
+		v.Length(validation.Pointer("customer_id"), req.CustomerID, 1, maxCustomerIDLength)
+	}
+
+	if req.ExpiresAt != nil {
+		v.Check(req.ExpiresAt.After(time.Now()), validation.Pointer("expires_at"), validation.CodeRange, "deve estar no futuro")
+	}
+
+	return v.Err()
+}
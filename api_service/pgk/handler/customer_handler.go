@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/models"
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/service"
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/validation"
+)
+
+type CustomerHandler struct {
+	service *service.CustomerService
+}
+
+func NewCustomerHandler(service *service.CustomerService) *CustomerHandler {
+	return &CustomerHandler{
+		service: service,
+	}
+}
+
+func (h *CustomerHandler) CreateCustomer(w http.ResponseWriter, r *http.Request) error {
+	var req models.CreateCustomerRequest
+	if err := validation.DecodeJSON(w, r, &req, maxRequestBodyBytes); err != nil {
+		return err
+	}
+
+	if err := validateCreateCustomerRequest(req); err != nil {
+		return err
+	}
+
+	customer, err := h.service.CreateCustomer(r.Context(), req)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Cliente criado com sucesso: %s", customer.CustomerID)
+
+	writeJSON(w, http.StatusCreated, customer)
+	return nil
+}
+
+func (h *CustomerHandler) GetCustomer(w http.ResponseWriter, r *http.Request) error {
+	customer, err := h.service.GetCustomer(r.Context(), r.PathValue("customer_id"))
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, http.StatusOK, customer)
+	return nil
+}
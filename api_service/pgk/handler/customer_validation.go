@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/models"
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/validation"
+)
+
+const (
+	maxCustomerNameLength = 200
+	maxEmailLength        = 254
+)
+
+var emailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+
+func validateCreateCustomerRequest(req models.CreateCustomerRequest) error {
+	var v validation.Validator
+
+	name := strings.TrimSpace(req.Name)
+	if v.Required(validation.Pointer("name"), name) && v.Length(validation.Pointer("name"), name, 1, maxCustomerNameLength) {
+		v.Printable(validation.Pointer("name"), name)
+	}
+
+	email := strings.TrimSpace(req.Email)
+	if v.Required(validation.Pointer("email"), email) && v.Length(validation.Pointer("email"), email, 1, maxEmailLength) {
+		v.Matches(validation.Pointer("email"), email, emailPattern, "deve ser um e-mail válido")
+	}
+
+	return v.Err()
+}
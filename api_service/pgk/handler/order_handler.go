@@ -2,13 +2,20 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/models"
 	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/service"
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/validation"
+	"github.com/dev-bruno-arruda/api-pedidos/domain"
 )
 
+// OrderHandler devolve os erros para o middleware.HandleErrors, que os
+// converte em problem+json; nenhum handler escreve respostas de erro.
 type OrderHandler struct {
 	service *service.OrderService
 }
@@ -19,40 +26,160 @@ func NewOrderHandler(service *service.OrderService) *OrderHandler {
 	}
 }
 
-func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Método não permitido", http.StatusMethodNotAllowed)
-		return
-	}
+func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) error {
+	idempotencyKey := r.Header.Get("Idempotency-Key")
 
 	var req models.CreateOrderRequest
+	if err := validation.DecodeJSON(w, r, &req, maxRequestBodyBytes); err != nil {
+		return err
+	}
+
+	if err := validateCreateOrderRequest(req, idempotencyKey); err != nil {
+		return err
+	}
+
+	response, replayed, err := h.service.CreateOrder(r.Context(), req, idempotencyKey)
+	if err != nil {
+		return err
+	}
+
+	if replayed {
+		w.Header().Set("Idempotent-Replayed", "true")
+		log.Printf("Pedido repetido pela Idempotency-Key: %s", response.OrderID)
+	} else {
+		log.Printf("Pedido criado com sucesso: %s", response.OrderID)
+	}
+
+	writeJSON(w, http.StatusCreated, response)
+	return nil
+}
+
+func (h *OrderHandler) GetOrder(w http.ResponseWriter, r *http.Request) error {
+	order, err := h.service.GetOrder(r.Context(), r.PathValue("order_id"))
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, http.StatusOK, order)
+	return nil
+}
+
+func (h *OrderHandler) GetOrderHistory(w http.ResponseWriter, r *http.Request) error {
+	response, err := h.service.GetOrderHistory(r.Context(), r.PathValue("order_id"))
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, http.StatusOK, response)
+	return nil
+}
+
+func (h *OrderHandler) CancelOrder(w http.ResponseWriter, r *http.Request) error {
+	orderID := r.PathValue("order_id")
+
+	// O corpo é opcional no cancelamento.
+	var req models.CancelOrderRequest
+	if err := validation.DecodeJSON(w, r, &req, maxRequestBodyBytes); err != nil && !errors.Is(err, validation.ErrEmptyBody) {
+		return err
+	}
+
+	if err := validateCancelOrderRequest(req); err != nil {
+		return err
+	}
 
-	err := json.NewDecoder(r.Body).Decode(&req)
+	response, err := h.service.CancelOrder(r.Context(), orderID, req.Reason)
 	if err != nil {
-		http.Error(w, "JSON inválido", http.StatusBadRequest)
-		return
+		return err
 	}
 
-	if req.Product == "" {
-		http.Error(w, "Campo 'product' é obrigatório", http.StatusBadRequest)
-		return
+	log.Printf("Pedido cancelado com sucesso: %s", response.OrderID)
+
+	writeJSON(w, http.StatusOK, response)
+	return nil
+}
+
+func (h *OrderHandler) ListOrders(w http.ResponseWriter, r *http.Request) error {
+	filter, err := parseOrderFilter(r)
+	if err != nil {
+		return err
 	}
 
-	if req.Quantity < 1 {
-		http.Error(w, "Campo 'quantity' deve ser maior que 0", http.StatusBadRequest)
-		return
+	response, err := h.service.ListOrders(r.Context(), filter)
+	if err != nil {
+		return err
 	}
 
-	response, err := h.service.CreateOrder(r.Context(), req)
+	writeJSON(w, http.StatusOK, response)
+	return nil
+}
+
+func (h *OrderHandler) ListCustomerOrders(w http.ResponseWriter, r *http.Request) error {
+	filter, err := parseOrderFilter(r)
 	if err != nil {
-		log.Printf("Erro ao criar pedido: %v", err)
-		http.Error(w, "Erro ao criar pedido", http.StatusInternalServerError)
-		return
+		return err
 	}
 
+	response, err := h.service.ListCustomerOrders(r.Context(), r.PathValue("customer_id"), filter)
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, http.StatusOK, response)
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(response)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func parseOrderFilter(r *http.Request) (models.OrderFilter, error) {
+	query := r.URL.Query()
+	filter := models.OrderFilter{
+		Status:  domain.Status(query.Get("status")),
+		Product: query.Get("product"),
+		SKU:     query.Get("sku"),
+		Cursor:  query.Get("cursor"),
+	}
+
+	var v validation.Validator
+
+	if filter.Status != "" && !filter.Status.IsValid() {
+		v.AddParameter("status", validation.CodeInvalidValue, "status inválido: "+string(filter.Status))
+	}
+
+	switch sort := query.Get("sort"); sort {
+	case "", "-created_at":
+	case "created_at":
+		filter.SortAsc = true
+	default:
+		v.AddParameter("sort", validation.CodeInvalidValue, "deve ser 'created_at' ou '-created_at'")
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			v.AddParameter("limit", validation.CodeRange, "deve ser um inteiro maior que 0")
+		}
+		filter.Limit = limit
+	}
+
+	if value := query.Get("created_from"); value != "" {
+		createdFrom, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			v.AddParameter("created_from", validation.CodeInvalidValue, "deve estar no formato RFC3339")
+		}
+		filter.CreatedFrom = &createdFrom
+	}
+
+	if value := query.Get("created_to"); value != "" {
+		createdTo, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			v.AddParameter("created_to", validation.CodeInvalidValue, "deve estar no formato RFC3339")
+		}
+		filter.CreatedTo = &createdTo
+	}
 
-	log.Printf("Pedido criado com sucesso: %s", response.OrderID)
+	return filter, v.Err()
 }
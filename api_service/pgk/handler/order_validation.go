@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/models"
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/validation"
+	"github.com/dev-bruno-arruda/api-pedidos/domain"
+)
+
+const (
+	maxRequestBodyBytes     = 256 << 10
+	maxIdempotencyKeyLength = 255
+	maxOrderItems           = 100
+	maxItemQuantity         = 10000
+	maxSKULength            = 64
+	maxItemNameLength       = 200
+	maxCancelReasonLength   = 500
+	maxCustomerIDLength     = 64
+)
+
+var (
+	skuPattern            = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+	idempotencyKeyPattern = regexp.MustCompile(`^[\x21-\x7E]+$`)
+)
+
+func validateCreateOrderRequest(req models.CreateOrderRequest, idempotencyKey string) error {
+	var v validation.Validator
+
+	if idempotencyKey != "" {
+		if len(idempotencyKey) > maxIdempotencyKeyLength || !idempotencyKeyPattern.MatchString(idempotencyKey) {
+			v.AddHeader("Idempotency-Key", validation.CodeInvalidValue,
+				fmt.Sprintf("deve ter até %d caracteres ASCII visíveis", maxIdempotencyKeyLength))
+		}
+	}
+
+	if v.Required(validation.Pointer("customer_id"), req.CustomerID) {
+		v.Length(validation.Pointer("customer_id"), req.CustomerID, 1, maxCustomerIDLength)
+	}
+
+	if len(req.Items) == 0 {
+		v.Add(validation.Pointer("items"), validation.CodeRequired, "o pedido deve ter ao menos um item")
+	}
+	v.Check(len(req.Items) <= maxOrderItems, validation.Pointer("items"), validation.CodeLength,
+		fmt.Sprintf("o pedido deve ter no máximo %d itens", maxOrderItems))
+
+	var currency string
+	for i, item := range req.Items {
+		pointer := func(field string) string { return validation.Pointer("items", i, field) }
+
+		if v.Required(pointer("sku"), item.SKU) && v.Length(pointer("sku"), item.SKU, 1, maxSKULength) {
+			v.Matches(pointer("sku"), item.SKU, skuPattern, "deve conter apenas letras, números, '.', '_' e '-'")
+		}
+
+		if v.Required(pointer("name"), item.Name) && v.Length(pointer("name"), item.Name, 1, maxItemNameLength) {
+			v.Printable(pointer("name"), item.Name)
+		}
+
+		v.Range(pointer("quantity"), int64(item.Quantity), 1, maxItemQuantity)
+
+		validCurrency := v.Check(domain.IsSupportedCurrency(item.Currency), pointer("currency"), validation.CodeInvalidValue,
+			"deve ser um código ISO 4217 suportado (ex.: BRL)")
+		if validCurrency {
+			if currency == "" {
+				currency = item.Currency
+			} else {
+				v.Check(item.Currency == currency, pointer("currency"), validation.CodeInvalidValue,
+					"todos os itens devem usar a mesma moeda")
+			}
+		}
+
+		if v.Required(pointer("unit_price"), item.UnitPrice.String()) && validCurrency {
+			unitPrice, err := domain.ParseMoney(item.UnitPrice.String(), item.Currency)
+			if v.Check(err == nil, pointer("unit_price"), validation.CodeInvalidValue,
+				fmt.Sprintf("deve ser um valor com no máximo as casas decimais de %s", item.Currency)) {
+				v.Check(!unitPrice.IsNegative(), pointer("unit_price"), validation.CodeRange, "não pode ser negativo")
+			}
+		}
+	}
+
+	v.Range(validation.Pointer("discount_bps"), req.DiscountBps, 0, 10000)
+
+	return v.Err()
+}
+
+func validateCancelOrderRequest(req models.CancelOrderRequest) error {
+	var v validation.Validator
+
+	if req.Reason != "" && v.Length(validation.Pointer("reason"), req.Reason, 1, maxCancelReasonLength) {
+		v.Printable(validation.Pointer("reason"), req.Reason)
+	}
+
+	return v.Err()
+}
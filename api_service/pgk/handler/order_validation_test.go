@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/models"
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/validation"
+)
+
+func decodeAndValidate(body string) error {
+	r := httptest.NewRequest("POST", "/orders", strings.NewReader(body))
+	var req models.CreateOrderRequest
+	if err := validation.DecodeJSON(httptest.NewRecorder(), r, &req, maxRequestBodyBytes); err != nil {
+		return err
+	}
+	return validateCreateOrderRequest(req, "")
+}
+
+func TestCreateOrderUnitPricePointer(t *testing.T) {
+	item := func(unitPrice string) string {
+		return `{"sku": "SKU-1", "name": "Item", "quantity": 1, "currency": "BRL", "unit_price": ` + unitPrice + `}`
+	}
+	body := func(unitPrice string) string {
+		return `{"customer_id": "c1", "items": [` + item(`10.00`) + `, ` + item(`"5.50"`) + `, ` + item(unitPrice) + `]}`
+	}
+
+	tests := []struct {
+		name      string
+		unitPrice string
+		code      string
+	}{
+		{name: "string que não é número", unitPrice: `"abc"`, code: validation.CodeInvalidValue},
+		{name: "casas decimais demais", unitPrice: `1.234`, code: validation.CodeInvalidValue},
+		{name: "booleano", unitPrice: `true`, code: validation.CodeInvalidValue},
+		{name: "objeto", unitPrice: `{"valor": 1}`, code: validation.CodeInvalidValue},
+		{name: "negativo", unitPrice: `"-1.00"`, code: validation.CodeRange},
+		{name: "nulo", unitPrice: `null`, code: validation.CodeRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var validationErr *validation.Error
+			if err := decodeAndValidate(body(tt.unitPrice)); !errors.As(err, &validationErr) {
+				t.Fatalf("erro = %v, esperado *validation.Error", err)
+			}
+			if len(validationErr.Violations) != 1 {
+				t.Fatalf("violações = %+v, esperado uma", validationErr.Violations)
+			}
+			v := validationErr.Violations[0]
+			if v.Pointer != "/items/2/unit_price" || v.Code != tt.code {
+				t.Fatalf("violação = %s %s, esperado /items/2/unit_price %s", v.Pointer, v.Code, tt.code)
+			}
+		})
+	}
+
+	if err := decodeAndValidate(body(`"7"`)); err != nil {
+		t.Fatalf("pedido válido: erro inesperado %v", err)
+	}
+}
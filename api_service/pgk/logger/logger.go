@@ -26,6 +26,12 @@ func Warnf(format string, args ...interface{}) {
 	log.Printf("[WARN] "+format, args...)
 }
 
+// Auditf registra eventos de segurança (ex.: acessos negados) com um prefixo
+// próprio, para serem filtrados separadamente dos logs de aplicação.
+func Auditf(format string, args ...interface{}) {
+	log.Printf("[AUDIT] "+format, args...)
+}
+
 func Debug(message string) {
 	log.Printf("[DEBUG] %s", message)
 }
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/auth"
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/logger"
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/problem"
+)
+
+// Authenticate exige credenciais válidas em todas as rotas, exceto nos
+// caminhos de publicPaths, e coloca o auth.Principal no contexto da requisição.
+// Os authenticators são tentados em ordem; vale o primeiro cuja credencial
+// está presente na requisição.
+func Authenticate(authenticators []auth.Authenticator, publicPaths ...string) func(http.Handler) http.Handler {
+	public := make(map[string]bool, len(publicPaths))
+	for _, path := range publicPaths {
+		public[path] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if public[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			principal, err := authenticate(r, authenticators)
+			if err != nil {
+				unauthorized(w, r, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+func authenticate(r *http.Request, authenticators []auth.Authenticator) (*auth.Principal, error) {
+	for _, authenticator := range authenticators {
+		principal, err := authenticator.Authenticate(r)
+		if errors.Is(err, auth.ErrMissingCredentials) {
+			continue
+		}
+		return principal, err
+	}
+	return nil, auth.ErrMissingCredentials
+}
+
+// RequireScope só executa next se o chamador tiver o escopo. Sem Principal
+// (autenticação desabilitada) a verificação é ignorada.
+func RequireScope(scope string, next HandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		principal, ok := auth.PrincipalFrom(r.Context())
+		if ok && !principal.HasScope(scope) {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="api-pedidos", error="insufficient_scope", scope=%q`, scope))
+			return fmt.Errorf("%w: %s sem o escopo %s", auth.ErrInsufficientScope, principal.Subject, scope)
+		}
+		return next(w, r)
+	}
+}
+
+// auditDenied registra quem teve o acesso negado e a que rota.
+func auditDenied(r *http.Request, err error) {
+	subject, customerID, method := "-", "-", "-"
+	if principal, ok := auth.PrincipalFrom(r.Context()); ok {
+		subject, method = principal.Subject, principal.Method
+		if principal.CustomerID != "" {
+			customerID = principal.CustomerID
+		}
+	}
+	logger.Auditf("acesso negado sub=%s customer_id=%s auth=%s ip=%s %s %s: %v",
+		subject, customerID, method, r.RemoteAddr, r.Method, r.URL.Path, err)
+}
+
+func unauthorized(w http.ResponseWriter, r *http.Request, err error) {
+	p := problem.FromError(err)
+	if p.Status != http.StatusUnauthorized {
+		// Falha ao consultar a credencial (ex.: MongoDB indisponível), não
+		// credencial inválida.
+		logger.Errorf("%s %s: %v", r.Method, r.URL.Path, err)
+		problem.Write(w, r, p)
+		return
+	}
+
+	logger.Warnf("%s %s: %v", r.Method, r.URL.Path, err)
+	challenge := `Bearer realm="api-pedidos"`
+	if errors.Is(err, auth.ErrInvalidToken) {
+		challenge += `, error="invalid_token"`
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+	problem.Write(w, r, p)
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/logger"
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/problem"
+)
+
+// HandlerFunc é um handler que devolve o erro em vez de escrevê-lo na resposta.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// HandleErrors é o único ponto que traduz erros em respostas HTTP: todo erro
+// vira um application/problem+json com um código estável.
+func HandleErrors(next HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := next(w, r)
+		if err == nil {
+			return
+		}
+
+		p := problem.FromError(err)
+		switch {
+		case p.Status >= http.StatusInternalServerError:
+			logger.Errorf("%s %s: %v", r.Method, r.URL.Path, err)
+		case p.Status == http.StatusForbidden:
+			auditDenied(r, err)
+		default:
+			logger.Warnf("%s %s: %v", r.Method, r.URL.Path, err)
+		}
+
+		problem.Write(w, r, p)
+	}
+}
@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/auth"
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/logger"
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/models"
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/ports"
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/problem"
+)
+
+// RateLimits aplica um token bucket por rota e por cliente. O cliente é a
+// chave de API, o sujeito do token ou, sem autenticação, o IP de origem.
+// perIP é o limite por IP aplicado antes da autenticação.
+type RateLimits struct {
+	limiter      ports.RateLimiter
+	defaultLimit models.RateLimit
+	routes       map[string]models.RateLimit
+	perIP        models.RateLimit
+	// trustForwardedFor usa o X-Forwarded-For para identificar o IP; só deve
+	// ser habilitado atrás de um proxy que sobrescreve o header.
+	trustForwardedFor bool
+}
+
+func NewRateLimits(limiter ports.RateLimiter, defaultLimit models.RateLimit, routes map[string]models.RateLimit, perIP models.RateLimit, trustForwardedFor bool) *RateLimits {
+	return &RateLimits{
+		limiter:           limiter,
+		defaultLimit:      defaultLimit,
+		routes:            routes,
+		perIP:             perIP,
+		trustForwardedFor: trustForwardedFor,
+	}
+}
+
+// LimitByIP limita as requisições por IP antes da autenticação, para que
+// tentativas com credenciais inválidas (401) também sejam contidas. Os
+// caminhos de publicPaths não são limitados.
+func (l *RateLimits) LimitByIP(publicPaths ...string) func(http.Handler) http.Handler {
+	public := make(map[string]bool, len(publicPaths))
+	for _, path := range publicPaths {
+		public[path] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if public[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if err := l.take(w, r, "ip|"+l.clientIP(r), l.perIP); err != nil {
+				logger.Warnf("%s %s: %v", r.Method, r.URL.Path, err)
+				problem.Write(w, r, problem.FromError(err))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Wrap limita a rota registrada no ServeMux com pattern. Se o limitador falhar
+// a requisição segue sem limite: a indisponibilidade dele não derruba a API.
+func (l *RateLimits) Wrap(pattern string, next HandlerFunc) HandlerFunc {
+	limit, ok := l.routes[pattern]
+	if !ok {
+		limit = l.defaultLimit
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) error {
+		if err := l.take(w, r, pattern+"|"+l.clientKey(r), limit); err != nil {
+			return err
+		}
+		return next(w, r)
+	}
+}
+
+// take consome um token do balde de key e escreve os headers de rate limit.
+// Os headers do limite por rota sobrescrevem os do limite por IP.
+func (l *RateLimits) take(w http.ResponseWriter, r *http.Request, key string, limit models.RateLimit) error {
+	decision, err := l.limiter.Allow(r.Context(), key, limit)
+	if err != nil {
+		logger.Errorf("Erro no rate limiting de %s, requisição liberada: %v", key, err)
+		return nil
+	}
+
+	header := w.Header()
+	header.Set("RateLimit-Limit", strconv.Itoa(decision.Limit))
+	header.Set("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+	header.Set("RateLimit-Reset", ceilSeconds(decision.Reset))
+	header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%s", limit.Limit, ceilSeconds(limit.Period)))
+
+	if !decision.Allowed {
+		header.Set("Retry-After", ceilSeconds(decision.RetryAfter))
+		return fmt.Errorf("%w: %s", models.ErrRateLimited, key)
+	}
+	return nil
+}
+
+func (l *RateLimits) clientKey(r *http.Request) string {
+	if principal, ok := auth.PrincipalFrom(r.Context()); ok {
+		if principal.Method == auth.MethodAPIKey {
+			return principal.Subject
+		}
+		return "sub:" + principal.Subject
+	}
+	return "ip:" + l.clientIP(r)
+}
+
+func (l *RateLimits) clientIP(r *http.Request) string {
+	if l.trustForwardedFor {
+		// A última entrada é a adicionada pelo proxy; as anteriores vêm do
+		// cliente e podem ser forjadas.
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			entries := strings.Split(forwarded, ",")
+			return strings.TrimSpace(entries[len(entries)-1])
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
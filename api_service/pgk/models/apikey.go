@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/apperror"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrAPIKeyNotFound = apperror.NotFound("api_key_not_found", "Chave de API não encontrada")
+
+// APIKey é uma chave de API de um sistema chamador. Apenas o hash SHA-256 da
+// chave é gravado; Prefix identifica a chave nas listagens.
+type APIKey struct {
+	ID         primitive.ObjectID `json:"-" bson:"_id,omitempty"`
+	KeyID      string             `json:"key_id" bson:"key_id"`
+	Name       string             `json:"name" bson:"name"`
+	Prefix     string             `json:"prefix" bson:"prefix"`
+	Hash       string             `json:"-" bson:"hash"`
+	Scopes     []string           `json:"scopes" bson:"scopes"`
+	CustomerID string             `json:"customer_id,omitempty" bson:"customer_id,omitempty"`
+	CreatedBy  string             `json:"created_by,omitempty" bson:"created_by,omitempty"`
+	CreatedAt  time.Time          `json:"created_at" bson:"created_at"`
+	ExpiresAt  *time.Time         `json:"expires_at,omitempty" bson:"expires_at,omitempty"`
+	LastUsedAt *time.Time         `json:"last_used_at,omitempty" bson:"last_used_at,omitempty"`
+	RevokedAt  *time.Time         `json:"revoked_at,omitempty" bson:"revoked_at,omitempty"`
+}
+
+// Active indica se a chave pode ser usada em now.
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+type CreateAPIKeyRequest struct {
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CustomerID string     `json:"customer_id,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+// CreateAPIKeyResponse é a única resposta que contém a chave em texto claro.
+type CreateAPIKeyResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+type ListAPIKeysResponse struct {
+	Data []APIKey `json:"data"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/apperror"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrCustomerNotFound   = apperror.NotFound("customer_not_found", "Cliente não encontrado")
+	ErrCustomerEmailInUse = apperror.Conflict("customer_email_in_use", "E-mail já cadastrado para outro cliente")
+	ErrUnknownCustomer    = apperror.Unprocessable("unknown_customer", "Cliente informado no pedido não existe")
+)
+
+type Customer struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	CustomerID string             `json:"customer_id" bson:"customer_id"`
+	Name       string             `json:"name" bson:"name"`
+	Email      string             `json:"email" bson:"email"`
+	CreatedAt  time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt  time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+type CreateCustomerRequest struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/apperror"
+)
+
+var (
+	ErrIdempotencyKeyInUse    = apperror.Conflict("idempotency_key_in_use", "Requisição com a mesma Idempotency-Key em andamento")
+	ErrIdempotencyKeyMismatch = apperror.Unprocessable("idempotency_key_mismatch", "Idempotency-Key já utilizada com outro corpo de requisição")
+)
+
+const (
+	IdempotencyStatusInProgress = "EM_ANDAMENTO"
+	IdempotencyStatusCompleted  = "CONCLUIDO"
+)
+
+// IdempotencyRecord guarda o resultado de um POST /orders identificado pela
+// Idempotency-Key do cliente. LockedUntil limita quanto tempo uma requisição em
+// andamento bloqueia a chave caso a instância caia antes de concluir; LockToken
+// identifica a requisição dona da reserva, inclusive depois de retomada.
+type IdempotencyRecord struct {
+	Key         string               `bson:"_id"`
+	RequestHash string               `bson:"request_hash"`
+	Status      string               `bson:"status"`
+	LockToken   string               `bson:"lock_token,omitempty"`
+	Response    *CreateOrderResponse `bson:"response,omitempty"`
+	LockedUntil time.Time            `bson:"locked_until"`
+	CreatedAt   time.Time            `bson:"created_at"`
+	CompletedAt *time.Time           `bson:"completed_at,omitempty"`
+}
@@ -1,37 +1,159 @@
 package models
 
 import (
-    "go.mongodb.org/mongo-driver/bson/primitive"
-    "time"
+	"encoding/json"
+	"time"
+
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/apperror"
+	"github.com/dev-bruno-arruda/api-pedidos/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-const (
-    StatusCriado       = "CRIADO"
-    StatusProcessando  = "PROCESSANDO"
-    StatusProcessado   = "PROCESSADO"
+var (
+	ErrOrderNotFound = apperror.NotFound("order_not_found", "Pedido não encontrado")
+	ErrInvalidCursor = apperror.Validation("invalid_cursor", "Parâmetro 'cursor' inválido")
 )
 
 type Order struct {
-    ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-    OrderID   string             `json:"order_id" bson:"order_id"`
-    Product   string             `json:"product" bson:"product"`
-    Quantity  int                `json:"quantity" bson:"quantity"`
-    Status    string             `json:"status" bson:"status"`
-    CreatedAt time.Time          `json:"created_at" bson:"created_at"`
-    UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	OrderID    string             `json:"order_id" bson:"order_id"`
+	CustomerID string             `json:"customer_id,omitempty" bson:"customer_id,omitempty"`
+	CreatedBy  string             `json:"created_by,omitempty" bson:"created_by,omitempty"`
+	Items      []domain.OrderItem `json:"items" bson:"items"`
+	Totals     domain.OrderTotals `json:"totals" bson:"totals"`
+	Status     domain.Status      `json:"status" bson:"status"`
+	CreatedAt  time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt  time.Time          `json:"updated_at" bson:"updated_at"`
+
+	StatusHistory []domain.StatusHistoryEntry `json:"status_history,omitempty" bson:"status_history,omitempty"`
+	// Payment, Saga e Steps são gravados pelo worker durante o processamento.
+	Payment *domain.Payment     `json:"payment,omitempty" bson:"payment,omitempty"`
+	Saga    *domain.SagaState   `json:"saga,omitempty" bson:"saga,omitempty"`
+	Steps   []domain.StepRecord `json:"steps,omitempty" bson:"steps,omitempty"`
+}
+
+type legacyOrderFields struct {
+	Product  string `bson:"product"`
+	Quantity int    `bson:"quantity"`
+}
+
+// UnmarshalBSON converte os pedidos antigos, gravados com product/quantity, em
+// um pedido com um único item.
+func (o *Order) UnmarshalBSON(data []byte) error {
+	type order Order
+	if err := bson.Unmarshal(data, (*order)(o)); err != nil {
+		return err
+	}
+
+	if len(o.Items) == 0 {
+		var legacy legacyOrderFields
+		if err := bson.Unmarshal(data, &legacy); err != nil {
+			return err
+		}
+		if legacy.Product != "" {
+			o.Items = []domain.OrderItem{domain.LegacyOrderItem(legacy.Product, legacy.Quantity)}
+		}
+	}
+
+	return nil
+}
+
+type OrderFilter struct {
+	CustomerID  string
+	Status      domain.Status
+	Product     string
+	SKU         string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	SortAsc     bool
+	Limit       int
+	Cursor      string
+}
+
+type OrderPage struct {
+	Orders     []Order
+	NextCursor string
+	Total      int64
 }
 
 type OrderMessage struct {
-    OrderID string `json:"order_id"`
-    Status  string `json:"status"`
+	MessageID string        `json:"message_id,omitempty" bson:"message_id,omitempty"`
+	OrderID   string        `json:"order_id" bson:"order_id"`
+	Status    domain.Status `json:"status" bson:"status"`
+}
+
+// UnitPrice é lido como Decimal para ser convertido em domain.Money sem
+// passar por float64.
+type CreateOrderItemRequest struct {
+	SKU       string  `json:"sku"`
+	Name      string  `json:"name"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice Decimal `json:"unit_price"`
+	Currency  string  `json:"currency"`
+}
+
+// Decimal guarda o valor como veio no JSON, número ou string, sem validá-lo na
+// decodificação: o formato é conferido por domain.ParseMoney na validação do
+// pedido, que aponta o item inválido (/items/2/unit_price).
+type Decimal string
+
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	switch {
+	case string(data) == "null":
+		return nil
+	case len(data) > 0 && data[0] == '"':
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*d = Decimal(s)
+	default:
+		*d = Decimal(data)
+	}
+	return nil
+}
+
+// MarshalJSON escreve o valor como número, como o json.Number fazia, para não
+// mudar o hash das requisições idempotentes.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(json.Number(d))
+}
+
+func (d Decimal) String() string {
+	return string(d)
 }
 
 type CreateOrderRequest struct {
-    Product  string `json:"product"`
-    Quantity int    `json:"quantity"`
+	CustomerID  string                   `json:"customer_id"`
+	Items       []CreateOrderItemRequest `json:"items"`
+	DiscountBps int64                    `json:"discount_bps,omitempty"`
 }
 
 type CreateOrderResponse struct {
-    OrderID string `json:"order_id"`
-    Status  string `json:"status"`
-}
\ No newline at end of file
+	OrderID string             `json:"order_id" bson:"order_id"`
+	Status  domain.Status      `json:"status" bson:"status"`
+	Totals  domain.OrderTotals `json:"totals" bson:"totals"`
+}
+
+type ListOrdersResponse struct {
+	Data       []Order `json:"data"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+	Total      int64   `json:"total"`
+}
+
+type CancelOrderRequest struct {
+	Reason string `json:"reason"`
+}
+
+type CancelOrderResponse struct {
+	OrderID        string        `json:"order_id"`
+	Status         domain.Status `json:"status"`
+	PreviousStatus domain.Status `json:"previous_status"`
+}
+
+type OrderHistoryResponse struct {
+	OrderID string                      `json:"order_id"`
+	Status  domain.Status               `json:"status"`
+	History []domain.StatusHistoryEntry `json:"history"`
+}
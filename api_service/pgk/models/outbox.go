@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	OutboxStatusPending = "PENDENTE"
+	OutboxStatusSent    = "ENVIADO"
+)
+
+type OutboxEntry struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty"`
+	OrderID       string             `bson:"order_id"`
+	Message       OrderMessage       `bson:"message"`
+	Status        string             `bson:"status"`
+	Attempts      int                `bson:"attempts"`
+	LastError     string             `bson:"last_error,omitempty"`
+	NextAttemptAt time.Time          `bson:"next_attempt_at"`
+	CreatedAt     time.Time          `bson:"created_at"`
+	SentAt        *time.Time         `bson:"sent_at,omitempty"`
+}
+
+func NewOutboxEntry(message OrderMessage, now time.Time) *OutboxEntry {
+	return &OutboxEntry{
+		OrderID:       message.OrderID,
+		Message:       message,
+		Status:        OutboxStatusPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+}
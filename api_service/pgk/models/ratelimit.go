@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/apperror"
+)
+
+var ErrRateLimited = apperror.RateLimited("rate_limit_exceeded", "Limite de requisições excedido, tente novamente mais tarde")
+
+// RateLimit define um token bucket com capacidade para Limit requisições,
+// recarregado por completo a cada Period.
+type RateLimit struct {
+	Limit  int
+	Period time.Duration
+}
+
+type RateLimitDecision struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset é o tempo até o balde voltar a ficar cheio.
+	Reset time.Duration
+	// RetryAfter é o tempo até o próximo token; só é preenchido quando a
+	// requisição foi recusada.
+	RetryAfter time.Duration
+}
@@ -0,0 +1,19 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/models"
+)
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *models.APIKey) error
+	// FindByHash retorna models.ErrAPIKeyNotFound se nenhuma chave tiver o hash.
+	FindByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	List(ctx context.Context) ([]models.APIKey, error)
+	// Revoke marca a chave como revogada e devolve o estado final; revogar uma
+	// chave já revogada não altera RevokedAt.
+	Revoke(ctx context.Context, keyID string, at time.Time) (*models.APIKey, error)
+	TouchLastUsed(ctx context.Context, keyID string, at time.Time) error
+}
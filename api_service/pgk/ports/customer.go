@@ -0,0 +1,13 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/models"
+)
+
+type CustomerRepository interface {
+	// Create retorna models.ErrCustomerEmailInUse se o e-mail já estiver cadastrado.
+	Create(ctx context.Context, customer *models.Customer) error
+	FindByCustomerID(ctx context.Context, customerID string) (*models.Customer, error)
+}
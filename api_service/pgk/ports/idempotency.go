@@ -0,0 +1,19 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/models"
+)
+
+type IdempotencyRepository interface {
+	// Reserve reserva a chave para a requisição identificada por lockToken até
+	// now+lock. Quando a chave já existe (e não está abandonada), retorna o
+	// registro existente.
+	Reserve(ctx context.Context, key, requestHash, lockToken string, now time.Time, lock time.Duration) (*models.IdempotencyRecord, error)
+	// Complete grava a resposta apenas se a reserva ainda for de lockToken;
+	// senão retorna models.ErrIdempotencyKeyInUse, abortando a transação.
+	Complete(ctx context.Context, key, lockToken string, response *models.CreateOrderResponse) error
+	Release(ctx context.Context, key, lockToken string) error
+}
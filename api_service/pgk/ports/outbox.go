@@ -0,0 +1,22 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type OutboxRepository interface {
+	Add(ctx context.Context, entry *models.OutboxEntry) error
+	// ClaimNext reserva a próxima entrada pendente até now+lease, para que outra
+	// instância não a publique em paralelo. Retorna nil quando não há pendências.
+	ClaimNext(ctx context.Context, now time.Time, lease time.Duration) (*models.OutboxEntry, error)
+	MarkSent(ctx context.Context, id primitive.ObjectID) error
+	MarkFailed(ctx context.Context, id primitive.ObjectID, nextAttemptAt time.Time, lastError string) error
+}
+
+type Transactor interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
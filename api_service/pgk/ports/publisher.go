@@ -2,10 +2,38 @@ package ports
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/models"
 )
 
+var (
+	ErrMessageNacked   = errors.New("mensagem rejeitada pelo broker")
+	ErrMessageReturned = errors.New("mensagem devolvida pelo broker por não ter rota")
+	ErrConfirmTimeout  = errors.New("tempo esgotado aguardando confirmação do broker")
+)
+
+// PublishError indica que o broker não aceitou a mensagem. Todos os casos são
+// transitórios do ponto de vista do serviço e a publicação pode ser repetida.
+type PublishError struct {
+	Err       error
+	MessageID string
+	ReplyCode uint16
+	ReplyText string
+}
+
+func (e *PublishError) Error() string {
+	if e.ReplyText != "" {
+		return fmt.Sprintf("%v (message_id=%s, %d %s)", e.Err, e.MessageID, e.ReplyCode, e.ReplyText)
+	}
+	return fmt.Sprintf("%v (message_id=%s)", e.Err, e.MessageID)
+}
+
+func (e *PublishError) Unwrap() error {
+	return e.Err
+}
+
 type MessagePublisher interface {
 	PublishOrderMessage(ctx context.Context, message models.OrderMessage) error
 	Close() error
@@ -0,0 +1,13 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/models"
+)
+
+type RateLimiter interface {
+	// Allow consome um token do balde identificado por key, criado cheio com
+	// os parâmetros de limit.
+	Allow(ctx context.Context, key string, limit models.RateLimit) (models.RateLimitDecision, error)
+}
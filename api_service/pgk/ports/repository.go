@@ -4,10 +4,12 @@ import (
 	"context"
 
 	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/models"
+	"github.com/dev-bruno-arruda/api-pedidos/domain"
 )
 
 type OrderRepository interface {
 	Create(ctx context.Context, order *models.Order) error
-	UpdateStatus(ctx context.Context, orderID string, status string) error
+	UpdateStatus(ctx context.Context, orderID string, change domain.StatusChange) error
 	FindByOrderID(ctx context.Context, orderID string) (*models.Order, error)
+	List(ctx context.Context, filter models.OrderFilter) (*models.OrderPage, error)
 }
@@ -0,0 +1,65 @@
+package problem
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/apperror"
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/validation"
+)
+
+var kindStatus = map[apperror.Kind]int{
+	apperror.KindUnauthenticated: http.StatusUnauthorized,
+	apperror.KindForbidden:       http.StatusForbidden,
+	apperror.KindNotFound:        http.StatusNotFound,
+	apperror.KindConflict:        http.StatusConflict,
+	apperror.KindValidation:      http.StatusBadRequest,
+	apperror.KindUnprocessable:   http.StatusUnprocessableEntity,
+	apperror.KindRateLimited:     http.StatusTooManyRequests,
+	apperror.KindUnavailable:     http.StatusServiceUnavailable,
+	apperror.KindInternal:        http.StatusInternalServerError,
+}
+
+// FromError converte qualquer erro retornado por um handler em um Problem.
+// Erros sem tipo viram 500 sem expor a causa ao cliente.
+func FromError(err error) Problem {
+	var validationErr *validation.Error
+
+	switch {
+	case errors.As(err, &validationErr):
+		return Validation(validationErr)
+	case errors.Is(err, validation.ErrEmptyBody):
+		return Validation(&validation.Error{Violations: []validation.Violation{
+			{Code: validation.CodeInvalidJSON, Detail: "corpo da requisição vazio"},
+		}})
+	case errors.Is(err, validation.ErrBodyTooLarge):
+		return BodyTooLarge(err.Error())
+	}
+
+	if appErr, ok := apperror.As(err); ok {
+		status, ok := kindStatus[appErr.Kind]
+		if !ok {
+			status = http.StatusInternalServerError
+		}
+		return Problem{
+			Type:   typeURI(appErr.Code),
+			Title:  http.StatusText(status),
+			Status: status,
+			Detail: appErr.Message,
+			Code:   appErr.Code,
+		}
+	}
+
+	return Problem{
+		Type:   typeURI("internal_error"),
+		Title:  http.StatusText(http.StatusInternalServerError),
+		Status: http.StatusInternalServerError,
+		Detail: "Erro interno do servidor",
+		Code:   "internal_error",
+	}
+}
+
+func typeURI(code string) string {
+	return "/problems/" + strings.ReplaceAll(code, "_", "-")
+}
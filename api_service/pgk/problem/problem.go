@@ -0,0 +1,59 @@
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/validation"
+)
+
+const ContentType = "application/problem+json"
+
+// Problem é o corpo de erro do RFC 7807. Code é uma extensão com um código
+// estável para os clientes; Errors lista as violações de validação por campo.
+type Problem struct {
+	Type     string                 `json:"type"`
+	Title    string                 `json:"title"`
+	Status   int                    `json:"status"`
+	Detail   string                 `json:"detail,omitempty"`
+	Instance string                 `json:"instance,omitempty"`
+	Code     string                 `json:"code,omitempty"`
+	Errors   []validation.Violation `json:"errors,omitempty"`
+}
+
+func Write(w http.ResponseWriter, r *http.Request, p Problem) {
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	if p.Instance == "" && r != nil {
+		p.Instance = r.URL.Path
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+func Validation(err *validation.Error) Problem {
+	return Problem{
+		Type:   "/problems/validation-error",
+		Title:  "Requisição inválida",
+		Status: http.StatusBadRequest,
+		Detail: "Um ou mais campos são inválidos",
+		Code:   "validation_error",
+		Errors: err.Violations,
+	}
+}
+
+func BodyTooLarge(detail string) Problem {
+	return Problem{
+		Type:   "/problems/body-too-large",
+		Title:  "Corpo da requisição muito grande",
+		Status: http.StatusRequestEntityTooLarge,
+		Detail: detail,
+		Code:   "body_too_large",
+	}
+}
@@ -0,0 +1,45 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/models"
+)
+
+// refillRate é quantos tokens o balde recebe por segundo.
+func refillRate(limit models.RateLimit) float64 {
+	return float64(limit.Limit) / limit.Period.Seconds()
+}
+
+// take recarrega o balde pelo tempo decorrido desde a última requisição e
+// consome um token, se houver. Devolve o novo saldo.
+func take(tokens float64, elapsed time.Duration, limit models.RateLimit) (float64, models.RateLimitDecision) {
+	if elapsed > 0 {
+		tokens = math.Min(float64(limit.Limit), tokens+elapsed.Seconds()*refillRate(limit))
+	}
+
+	allowed := tokens >= 1
+	if allowed {
+		tokens--
+	}
+	return tokens, decision(tokens, allowed, limit)
+}
+
+func decision(tokens float64, allowed bool, limit models.RateLimit) models.RateLimitDecision {
+	rate := refillRate(limit)
+	d := models.RateLimitDecision{
+		Allowed:   allowed,
+		Limit:     limit.Limit,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     seconds((float64(limit.Limit) - tokens) / rate),
+	}
+	if !allowed {
+		d.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	return d
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/models"
+)
+
+const sweepInterval = time.Minute
+
+// MemoryLimiter guarda os baldes na memória do processo. Com várias réplicas
+// da API cada uma aplica o limite separadamente; use o MongoLimiter nesse caso.
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+type memoryBucket struct {
+	tokens    float64
+	updatedAt time.Time
+	fullAt    time.Time
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		buckets:   make(map[string]*memoryBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+func (l *MemoryLimiter) Allow(ctx context.Context, key string, limit models.RateLimit) (models.RateLimitDecision, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &memoryBucket{tokens: float64(limit.Limit), updatedAt: now}
+		l.buckets[key] = bucket
+	}
+
+	tokens, decision := take(bucket.tokens, now.Sub(bucket.updatedAt), limit)
+	bucket.tokens = tokens
+	bucket.updatedAt = now
+	bucket.fullAt = now.Add(decision.Reset)
+
+	return decision, nil
+}
+
+// sweep descarta os baldes que já voltaram a ficar cheios: eles equivalem a
+// um balde novo, então não precisam ocupar memória.
+func (l *MemoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, bucket := range l.buckets {
+		if !now.Before(bucket.fullAt) {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoTimeout é curto porque o limitador está no caminho de toda requisição.
+const mongoTimeout = time.Second
+
+// MongoLimiter guarda os baldes no MongoDB, compartilhados entre as réplicas
+// da API. Cada requisição é um único update atômico: a recarga e o consumo do
+// token são calculados pelo próprio MongoDB com o relógio do servidor ($$NOW),
+// então relógios diferentes entre réplicas não afetam o limite.
+type MongoLimiter struct {
+	collection *mongo.Collection
+}
+
+type mongoBucket struct {
+	Tokens  float64 `bson:"tokens"`
+	Allowed bool    `bson:"allowed"`
+}
+
+func NewMongoLimiter(client *mongo.Client, dbName, collectionName string) *MongoLimiter {
+	return &MongoLimiter{
+		collection: client.Database(dbName).Collection(collectionName),
+	}
+}
+
+// EnsureIndexes cria o índice TTL que remove baldes parados: depois de um
+// período sem requisições o balde estaria cheio de novo.
+func (l *MongoLimiter) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	_, err := l.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return fmt.Errorf("erro ao criar índices de rate limiting: %w", err)
+	}
+
+	return nil
+}
+
+func (l *MongoLimiter) Allow(ctx context.Context, key string, limit models.RateLimit) (models.RateLimitDecision, error) {
+	ctx, cancel := context.WithTimeout(ctx, mongoTimeout)
+	defer cancel()
+
+	capacity := float64(limit.Limit)
+	elapsedSeconds := bson.M{"$divide": bson.A{
+		bson.M{"$subtract": bson.A{"$$NOW", bson.M{"$ifNull": bson.A{"$updated_at", "$$NOW"}}}},
+		1000,
+	}}
+	refilled := bson.M{"$min": bson.A{
+		capacity,
+		bson.M{"$add": bson.A{
+			bson.M{"$ifNull": bson.A{"$tokens", capacity}},
+			bson.M{"$multiply": bson.A{bson.M{"$max": bson.A{elapsedSeconds, 0}}, refillRate(limit)}},
+		}},
+	}}
+	hasToken := bson.M{"$gte": bson.A{"$tokens", 1}}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"tokens": refilled}}},
+		{{Key: "$set", Value: bson.M{
+			"allowed":    hasToken,
+			"tokens":     bson.M{"$cond": bson.A{hasToken, bson.M{"$subtract": bson.A{"$tokens", 1}}, "$tokens"}},
+			"updated_at": "$$NOW",
+			"expires_at": bson.M{"$add": bson.A{"$$NOW", limit.Period.Milliseconds()}},
+		}}},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var bucket mongoBucket
+	err := l.collection.FindOneAndUpdate(ctx, bson.M{"_id": key}, pipeline, opts).Decode(&bucket)
+	if mongo.IsDuplicateKeyError(err) {
+		// Outra réplica criou o balde ao mesmo tempo; agora ele existe.
+		err = l.collection.FindOneAndUpdate(ctx, bson.M{"_id": key}, pipeline, opts).Decode(&bucket)
+	}
+	if err != nil {
+		return models.RateLimitDecision{}, fmt.Errorf("erro ao consumir token de %s: %w", key, err)
+	}
+
+	return decision(bucket.Tokens, bucket.Allowed, limit), nil
+}
@@ -0,0 +1,52 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/models"
+)
+
+// ParseLimit lê um limite no formato "<requisições>/<período>", ex.: "120/1m".
+func ParseLimit(value string) (models.RateLimit, error) {
+	count, period, ok := strings.Cut(strings.TrimSpace(value), "/")
+	if !ok {
+		return models.RateLimit{}, fmt.Errorf("limite %q inválido: use <requisições>/<período>, ex.: 120/1m", value)
+	}
+
+	limit, err := strconv.Atoi(count)
+	if err != nil || limit < 1 {
+		return models.RateLimit{}, fmt.Errorf("limite %q inválido: requisições deve ser um inteiro maior que 0", value)
+	}
+	duration, err := time.ParseDuration(period)
+	if err != nil || duration <= 0 {
+		return models.RateLimit{}, fmt.Errorf("limite %q inválido: período deve ser uma duração positiva", value)
+	}
+
+	return models.RateLimit{Limit: limit, Period: duration}, nil
+}
+
+// ParseRoutes lê limites por rota no formato "<rota>=<limite>;...", ex.:
+// "POST /orders=30/1m;GET /orders=300/1m". A rota é o padrão usado no
+// registro do handler no ServeMux.
+func ParseRoutes(value string) (map[string]models.RateLimit, error) {
+	routes := make(map[string]models.RateLimit)
+	for _, entry := range strings.Split(value, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		route, limitValue, ok := strings.Cut(entry, "=")
+		route = strings.TrimSpace(route)
+		if !ok || route == "" {
+			return nil, fmt.Errorf("limite por rota %q inválido: use <rota>=<limite>", entry)
+		}
+		limit, err := ParseLimit(limitValue)
+		if err != nil {
+			return nil, fmt.Errorf("rota %s: %w", route, err)
+		}
+		routes[route] = limit
+	}
+	return routes, nil
+}
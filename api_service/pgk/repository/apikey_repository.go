@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type APIKeyRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAPIKeyRepository(client *mongo.Client, dbName, collectionName string) *APIKeyRepository {
+	return &APIKeyRepository{
+		collection: client.Database(dbName).Collection(collectionName),
+	}
+}
+
+func (r *APIKeyRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "key_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+
+	_, err := r.collection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		return fmt.Errorf("erro ao criar índices de chaves de API: %w", err)
+	}
+
+	return nil
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if _, err := r.collection.InsertOne(ctx, key); err != nil {
+		return storageError("erro ao criar chave de API", err)
+	}
+
+	return nil
+}
+
+func (r *APIKeyRepository) FindByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var key models.APIKey
+	err := r.collection.FindOne(ctx, bson.M{"hash": hash}).Decode(&key)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, models.ErrAPIKeyNotFound
+		}
+		return nil, storageError("erro ao buscar chave de API", err)
+	}
+
+	return &key, nil
+}
+
+func (r *APIKeyRepository) List(ctx context.Context) ([]models.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, storageError("erro ao listar chaves de API", err)
+	}
+	defer cursor.Close(ctx)
+
+	keys := []models.APIKey{}
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, storageError("erro ao decodificar chaves de API", err)
+	}
+
+	return keys, nil
+}
+
+func (r *APIKeyRepository) Revoke(ctx context.Context, keyID string, at time.Time) (*models.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var key models.APIKey
+	err := r.collection.FindOneAndUpdate(ctx,
+		bson.M{"key_id": keyID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": at}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&key)
+	if err == mongo.ErrNoDocuments {
+		err = r.collection.FindOne(ctx, bson.M{"key_id": keyID}).Decode(&key)
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("%w: %s", models.ErrAPIKeyNotFound, keyID)
+		}
+	}
+	if err != nil {
+		return nil, storageError("erro ao revogar chave de API", err)
+	}
+
+	return &key, nil
+}
+
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, keyID string, at time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"key_id": keyID}, bson.M{"$set": bson.M{"last_used_at": at}})
+	if err != nil {
+		return storageError("erro ao atualizar último uso da chave de API", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CustomerRepository struct {
+	collection *mongo.Collection
+}
+
+func NewCustomerRepository(client *mongo.Client, dbName, collectionName string) *CustomerRepository {
+	return &CustomerRepository{
+		collection: client.Database(dbName).Collection(collectionName),
+	}
+}
+
+func (r *CustomerRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "customer_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "email", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+
+	_, err := r.collection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		return fmt.Errorf("erro ao criar índices de clientes: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CustomerRepository) Create(ctx context.Context, customer *models.Customer) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := r.collection.InsertOne(ctx, customer)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("%w: %s", models.ErrCustomerEmailInUse, customer.Email)
+		}
+		return storageError("erro ao criar cliente", err)
+	}
+
+	return nil
+}
+
+func (r *CustomerRepository) FindByCustomerID(ctx context.Context, customerID string) (*models.Customer, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var customer models.Customer
+	err := r.collection.FindOne(ctx, bson.M{"customer_id": customerID}).Decode(&customer)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("%w: %s", models.ErrCustomerNotFound, customerID)
+		}
+		return nil, storageError("erro ao buscar cliente", err)
+	}
+
+	return &customer, nil
+}
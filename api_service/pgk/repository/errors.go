@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/apperror"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// storageError acrescenta contexto ao erro do driver e marca falhas de rede,
+// timeouts e cliente desconectado como indisponibilidade do banco, para não
+// serem confundidas com erros de programação.
+func storageError(message string, err error) error {
+	wrapped := fmt.Errorf("%s: %w", message, err)
+	if mongo.IsNetworkError(err) || mongo.IsTimeout(err) || errors.Is(err, mongo.ErrClientDisconnected) {
+		return apperror.Unavailable("storage_unavailable", "Banco de dados indisponível, tente novamente", wrapped)
+	}
+	return wrapped
+}
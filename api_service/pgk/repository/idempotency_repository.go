@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// createdAtIndexName é o nome padrão que o MongoDB deu ao índice TTL quando
+	// ele era criado sem nome, mantido para não duplicá-lo.
+	createdAtIndexName = "created_at_1"
+	// Código retornado pelo MongoDB quando um índice já existe com outras opções.
+	indexOptionsConflictCode = 85
+)
+
+type IdempotencyRepository struct {
+	collection *mongo.Collection
+}
+
+func NewIdempotencyRepository(client *mongo.Client, dbName, collectionName string) *IdempotencyRepository {
+	return &IdempotencyRepository{
+		collection: client.Database(dbName).Collection(collectionName),
+	}
+}
+
+// EnsureIndexes cria o índice TTL das chaves. Se o índice já existir com outro
+// TTL (IDEMPOTENCY_TTL alterado), ele é atualizado com collMod.
+func (r *IdempotencyRepository) EnsureIndexes(ctx context.Context, ttl time.Duration) error {
+	// O TTL do índice é em segundos inteiros: abaixo de 1s as chaves
+	// expirariam imediatamente.
+	if ttl < time.Second || ttl.Seconds() > math.MaxInt32 {
+		return fmt.Errorf("TTL de idempotência inválido: %s (deve estar entre 1s e %ds)", ttl, math.MaxInt32)
+	}
+	expireAfter := int32(ttl.Seconds())
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// As chaves expiram após o TTL; depois disso a mesma chave cria um novo pedido.
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "created_at", Value: 1}},
+		Options: options.Index().SetName(createdAtIndexName).SetExpireAfterSeconds(expireAfter),
+	})
+
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Code == indexOptionsConflictCode {
+		err = r.collection.Database().RunCommand(ctx, bson.D{
+			{Key: "collMod", Value: r.collection.Name()},
+			{Key: "index", Value: bson.D{
+				{Key: "name", Value: createdAtIndexName},
+				{Key: "expireAfterSeconds", Value: expireAfter},
+			}},
+		}).Err()
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao criar índices de idempotência: %w", err)
+	}
+
+	return nil
+}
+
+func (r *IdempotencyRepository) Reserve(ctx context.Context, key, requestHash, lockToken string, now time.Time, lock time.Duration) (*models.IdempotencyRecord, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := r.collection.InsertOne(ctx, models.IdempotencyRecord{
+		Key:         key,
+		RequestHash: requestHash,
+		Status:      models.IdempotencyStatusInProgress,
+		LockToken:   lockToken,
+		LockedUntil: now.Add(lock),
+		CreatedAt:   now,
+	})
+	if err == nil {
+		return nil, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, storageError("erro ao reservar Idempotency-Key", err)
+	}
+
+	// Uma reserva cuja instância caiu antes de concluir pode ser retomada pela
+	// mesma requisição depois que o lock expira.
+	filter := bson.M{
+		"_id":          key,
+		"request_hash": requestHash,
+		"status":       models.IdempotencyStatusInProgress,
+		"locked_until": bson.M{"$lt": now},
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{
+		"lock_token":   lockToken,
+		"locked_until": now.Add(lock),
+	}})
+	if err != nil {
+		return nil, storageError("erro ao reservar Idempotency-Key", err)
+	}
+	if result.MatchedCount > 0 {
+		return nil, nil
+	}
+
+	var record models.IdempotencyRecord
+	err = r.collection.FindOne(ctx, bson.M{"_id": key}).Decode(&record)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			// Expirou pelo TTL entre as duas operações; o cliente pode tentar de novo.
+			return nil, fmt.Errorf("%w: %s", models.ErrIdempotencyKeyInUse, key)
+		}
+		return nil, storageError("erro ao buscar Idempotency-Key", err)
+	}
+
+	return &record, nil
+}
+
+// Complete roda na transação do pedido. Se o lock expirou e outra requisição
+// retomou a chave, nada é atualizado e o erro aborta a transação: só a dona
+// atual da reserva grava pedido, outbox e resposta.
+func (r *IdempotencyRepository) Complete(ctx context.Context, key, lockToken string, response *models.CreateOrderResponse) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"_id":        key,
+		"status":     models.IdempotencyStatusInProgress,
+		"lock_token": lockToken,
+	}
+	update := bson.M{"$set": bson.M{
+		"status":       models.IdempotencyStatusCompleted,
+		"response":     response,
+		"completed_at": time.Now(),
+	}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return storageError("erro ao concluir Idempotency-Key", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: %s (reserva retomada por outra requisição)", models.ErrIdempotencyKeyInUse, key)
+	}
+
+	return nil
+}
+
+func (r *IdempotencyRepository) Release(ctx context.Context, key, lockToken string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := r.collection.DeleteOne(ctx, bson.M{
+		"_id":        key,
+		"status":     models.IdempotencyStatusInProgress,
+		"lock_token": lockToken,
+	})
+	if err != nil {
+		return storageError("erro ao liberar Idempotency-Key", err)
+	}
+
+	return nil
+}
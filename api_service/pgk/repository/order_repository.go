@@ -2,11 +2,15 @@ package repository
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/models"
+	"github.com/dev-bruno-arruda/api-pedidos/domain"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -27,29 +31,50 @@ func (r *OrderRepository) Create(ctx context.Context, order *models.Order) error
 
 	_, err := r.collection.InsertOne(ctx, order)
 	if err != nil {
-		return fmt.Errorf("erro ao criar pedido: %w", err)
+		return storageError("erro ao criar pedido", err)
 	}
 
 	return nil
 }
 
-func (r *OrderRepository) UpdateStatus(ctx context.Context, orderID string, status string) error {
+func (r *OrderRepository) UpdateStatus(ctx context.Context, orderID string, change domain.StatusChange) error {
+	if err := domain.ValidateTransition(change.From, change.To); err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	filter := bson.M{"order_id": orderID}
-	update := bson.M{"$set": bson.M{
-		"status":     status,
-		"updated_at": time.Now(),
-	}}
+	now := time.Now()
+
+	// O filtro pelo status esperado torna a transição atômica: se outro processo
+	// alterou o pedido nesse meio tempo, nenhum documento é atualizado. A entrada
+	// do histórico é gravada na mesma operação.
+	filter := bson.M{"order_id": orderID, "status": change.From}
+	update := bson.M{
+		"$set": bson.M{
+			"status":     change.To,
+			"updated_at": now,
+		},
+		"$push": bson.M{"status_history": change.Entry(now)},
+	}
 
 	result, err := r.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
-		return fmt.Errorf("erro ao atualizar status do pedido: %w", err)
+		return storageError("erro ao atualizar status do pedido", err)
 	}
 
 	if result.MatchedCount == 0 {
-		return fmt.Errorf("pedido não encontrado: %s", orderID)
+		var current models.Order
+		err := r.collection.FindOne(ctx, bson.M{"order_id": orderID},
+			options.FindOne().SetProjection(bson.M{"status": 1})).Decode(&current)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				return fmt.Errorf("%w: %s", models.ErrOrderNotFound, orderID)
+			}
+			return storageError("erro ao verificar status do pedido", err)
+		}
+		return &domain.TransitionError{OrderID: orderID, From: change.From, To: change.To, Current: current.Status}
 	}
 
 	return nil
@@ -65,14 +90,170 @@ func (r *OrderRepository) FindByOrderID(ctx context.Context, orderID string) (*m
 	err := r.collection.FindOne(ctx, filter).Decode(&order)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("pedido não encontrado: %s", orderID)
+			return nil, fmt.Errorf("%w: %s", models.ErrOrderNotFound, orderID)
 		}
-		return nil, fmt.Errorf("erro ao buscar pedido: %w", err)
+		return nil, storageError("erro ao buscar pedido", err)
 	}
 
 	return &order, nil
 }
 
+func (r *OrderRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "order_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "product", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "items.name", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "items.sku", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "customer_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+		},
+	}
+
+	_, err := r.collection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		return fmt.Errorf("erro ao criar índices de pedidos: %w", err)
+	}
+
+	return nil
+}
+
+func (r *OrderRepository) List(ctx context.Context, filter models.OrderFilter) (*models.OrderPage, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := bson.M{}
+	if filter.CustomerID != "" {
+		query["customer_id"] = filter.CustomerID
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	if filter.Product != "" {
+		// Pedidos antigos guardam o produto no campo product.
+		query["$or"] = bson.A{
+			bson.M{"items.name": filter.Product},
+			bson.M{"product": filter.Product},
+		}
+	}
+	if filter.SKU != "" {
+		query["items.sku"] = filter.SKU
+	}
+	if filter.CreatedFrom != nil || filter.CreatedTo != nil {
+		createdAt := bson.M{}
+		if filter.CreatedFrom != nil {
+			createdAt["$gte"] = *filter.CreatedFrom
+		}
+		if filter.CreatedTo != nil {
+			createdAt["$lte"] = *filter.CreatedTo
+		}
+		query["created_at"] = createdAt
+	}
+
+	total, err := r.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, storageError("erro ao contar pedidos", err)
+	}
+
+	direction := -1
+	comparison := "$lt"
+	if filter.SortAsc {
+		direction = 1
+		comparison = "$gt"
+	}
+
+	pageQuery := query
+	if filter.Cursor != "" {
+		cursor, err := decodeOrderCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		pageQuery = bson.M{"$and": bson.A{
+			query,
+			bson.M{"$or": bson.A{
+				bson.M{"created_at": bson.M{comparison: cursor.CreatedAt}},
+				bson.M{"created_at": cursor.CreatedAt, "_id": bson.M{comparison: cursor.ID}},
+			}},
+		}}
+	}
+
+	findOptions := options.Find().
+		SetProjection(bson.M{"status_history": 0}).
+		SetSort(bson.D{{Key: "created_at", Value: direction}, {Key: "_id", Value: direction}}).
+		SetLimit(int64(filter.Limit + 1))
+
+	cur, err := r.collection.Find(ctx, pageQuery, findOptions)
+	if err != nil {
+		return nil, storageError("erro ao listar pedidos", err)
+	}
+	defer cur.Close(ctx)
+
+	orders := make([]models.Order, 0, filter.Limit+1)
+	if err := cur.All(ctx, &orders); err != nil {
+		return nil, storageError("erro ao decodificar pedidos", err)
+	}
+
+	page := &models.OrderPage{Total: total}
+	if len(orders) > filter.Limit {
+		orders = orders[:filter.Limit]
+		last := orders[len(orders)-1]
+		page.NextCursor, err = encodeOrderCursor(orderCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+		if err != nil {
+			return nil, err
+		}
+	}
+	page.Orders = orders
+
+	return page, nil
+}
+
+type orderCursor struct {
+	CreatedAt time.Time          `json:"c"`
+	ID        primitive.ObjectID `json:"i"`
+}
+
+func encodeOrderCursor(cursor orderCursor) (string, error) {
+	raw, err := json.Marshal(cursor)
+	if err != nil {
+		return "", fmt.Errorf("erro ao gerar cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func decodeOrderCursor(value string) (orderCursor, error) {
+	var cursor orderCursor
+
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, fmt.Errorf("%w: %v", models.ErrInvalidCursor, err)
+	}
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return cursor, fmt.Errorf("%w: %v", models.ErrInvalidCursor, err)
+	}
+	if cursor.ID.IsZero() {
+		return cursor, models.ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
+
 type MongoDBConfig struct {
 	URI             string
 	Database        string
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const sentOutboxRetention = 7 * 24 * time.Hour
+
+type OutboxRepository struct {
+	collection *mongo.Collection
+}
+
+func NewOutboxRepository(client *mongo.Client, dbName, collectionName string) *OutboxRepository {
+	return &OutboxRepository{
+		collection: client.Database(dbName).Collection(collectionName),
+	}
+}
+
+func (r *OutboxRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}},
+		},
+		{
+			// Entradas enviadas são removidas automaticamente após o período de retenção.
+			Keys:    bson.D{{Key: "sent_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(sentOutboxRetention.Seconds())),
+		},
+	}
+
+	_, err := r.collection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		return fmt.Errorf("erro ao criar índices do outbox: %w", err)
+	}
+
+	return nil
+}
+
+func (r *OutboxRepository) Add(ctx context.Context, entry *models.OutboxEntry) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := r.collection.InsertOne(ctx, entry)
+	if err != nil {
+		return storageError("erro ao gravar mensagem no outbox", err)
+	}
+
+	return nil
+}
+
+func (r *OutboxRepository) ClaimNext(ctx context.Context, now time.Time, lease time.Duration) (*models.OutboxEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"status":          models.OutboxStatusPending,
+		"next_attempt_at": bson.M{"$lte": now},
+	}
+	update := bson.M{
+		"$set": bson.M{"next_attempt_at": now.Add(lease)},
+		"$inc": bson.M{"attempts": 1},
+	}
+	findOptions := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var entry models.OutboxEntry
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, findOptions).Decode(&entry)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, storageError("erro ao buscar mensagem pendente no outbox", err)
+	}
+
+	return &entry, nil
+}
+
+func (r *OutboxRepository) MarkSent(ctx context.Context, id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	update := bson.M{
+		"$set":   bson.M{"status": models.OutboxStatusSent, "sent_at": time.Now()},
+		"$unset": bson.M{"last_error": ""},
+	}
+
+	_, err := r.collection.UpdateByID(ctx, id, update)
+	if err != nil {
+		return storageError("erro ao marcar mensagem do outbox como enviada", err)
+	}
+
+	return nil
+}
+
+func (r *OutboxRepository) MarkFailed(ctx context.Context, id primitive.ObjectID, nextAttemptAt time.Time, lastError string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{
+		"next_attempt_at": nextAttemptAt,
+		"last_error":      lastError,
+	}}
+
+	_, err := r.collection.UpdateByID(ctx, id, update)
+	if err != nil {
+		return storageError("erro ao registrar falha no outbox", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/auth"
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/logger"
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/models"
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/ports"
+	"github.com/google/uuid"
+)
+
+const (
+	apiKeyPrefix      = "pk_"
+	apiKeySecretBytes = 32
+	// apiKeyDisplayLength é quanto da chave fica gravado em claro para
+	// identificá-la nas listagens.
+	apiKeyDisplayLength = 11
+	// lastUsedResolution limita a gravação do último uso a uma por intervalo,
+	// para que toda requisição não vire uma escrita no MongoDB.
+	lastUsedResolution = time.Minute
+)
+
+// APIKeyService gerencia as chaves de API e implementa auth.APIKeyVerifier.
+type APIKeyService struct {
+	repo ports.APIKeyRepository
+}
+
+func NewAPIKeyService(repo ports.APIKeyRepository) *APIKeyService {
+	return &APIKeyService{repo: repo}
+}
+
+// CreateAPIKey gera uma nova chave. A chave em texto claro só existe na
+// resposta; apenas o hash é gravado.
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, req models.CreateAPIKeyRequest) (*models.CreateAPIKeyResponse, error) {
+	// O chamador não pode conceder escopos que ele próprio não tem.
+	if principal, ok := auth.PrincipalFrom(ctx); ok {
+		for _, scope := range req.Scopes {
+			if !principal.HasScope(scope) {
+				return nil, fmt.Errorf("%w: %s não pode conceder o escopo %s", auth.ErrInsufficientScope, principal.Subject, scope)
+			}
+		}
+	}
+
+	key, err := generateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := slices.Clone(req.Scopes)
+	slices.Sort(scopes)
+
+	apiKey := models.APIKey{
+		KeyID:      uuid.New().String(),
+		Name:       strings.TrimSpace(req.Name),
+		Prefix:     key[:apiKeyDisplayLength],
+		Hash:       hashAPIKey(key),
+		Scopes:     slices.Compact(scopes),
+		CustomerID: req.CustomerID,
+		CreatedBy:  subject(ctx),
+		CreatedAt:  time.Now(),
+		ExpiresAt:  req.ExpiresAt,
+	}
+
+	if err := s.repo.Create(ctx, &apiKey); err != nil {
+		return nil, fmt.Errorf("erro ao salvar a chave de API: %w", err)
+	}
+
+	logger.Infof("Chave de API %s (%s) criada por %s com escopos %v", apiKey.KeyID, apiKey.Name, apiKey.CreatedBy, apiKey.Scopes)
+	return &models.CreateAPIKeyResponse{APIKey: apiKey, Key: key}, nil
+}
+
+func (s *APIKeyService) ListAPIKeys(ctx context.Context) (*models.ListAPIKeysResponse, error) {
+	keys, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar chaves de API: %w", err)
+	}
+
+	return &models.ListAPIKeysResponse{Data: keys}, nil
+}
+
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, keyID string) (*models.APIKey, error) {
+	apiKey, err := s.repo.Revoke(ctx, keyID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("erro ao revogar a chave de API: %w", err)
+	}
+
+	logger.Infof("Chave de API %s revogada por %s", keyID, subject(ctx))
+	return apiKey, nil
+}
+
+// VerifyAPIKey devolve o chamador dono da chave. Chaves desconhecidas,
+// revogadas ou expiradas retornam auth.ErrInvalidAPIKey.
+func (s *APIKeyService) VerifyAPIKey(ctx context.Context, key string) (*auth.Principal, error) {
+	apiKey, err := s.repo.FindByHash(ctx, hashAPIKey(key))
+	if errors.Is(err, models.ErrAPIKeyNotFound) {
+		return nil, fmt.Errorf("%w: chave desconhecida", auth.ErrInvalidAPIKey)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao verificar a chave de API: %w", err)
+	}
+
+	now := time.Now()
+	if !apiKey.Active(now) {
+		return nil, fmt.Errorf("%w: chave %s revogada ou expirada", auth.ErrInvalidAPIKey, apiKey.KeyID)
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= lastUsedResolution {
+		if err := s.repo.TouchLastUsed(context.WithoutCancel(ctx), apiKey.KeyID, now); err != nil {
+			logger.Warnf("Erro ao registrar uso da chave de API %s: %v", apiKey.KeyID, err)
+		}
+	}
+
+	return &auth.Principal{
+		Subject:    "api_key:" + apiKey.KeyID,
+		Scopes:     apiKey.Scopes,
+		CustomerID: apiKey.CustomerID,
+		Method:     auth.MethodAPIKey,
+	}, nil
+}
+
+func generateAPIKey() (string, error) {
+	secret := make([]byte, apiKeySecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("erro ao gerar chave de API: %w", err)
+	}
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// hashAPIKey usa SHA-256 sem salt: a chave tem 256 bits aleatórios, então não
+// há dicionário a atacar, e o hash determinístico permite buscar pelo índice.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
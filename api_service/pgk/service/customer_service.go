@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/auth"
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/logger"
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/models"
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/ports"
+	"github.com/google/uuid"
+)
+
+type CustomerService struct {
+	repo ports.CustomerRepository
+}
+
+func NewCustomerService(repo ports.CustomerRepository) *CustomerService {
+	return &CustomerService{repo: repo}
+}
+
+func (s *CustomerService) CreateCustomer(ctx context.Context, req models.CreateCustomerRequest) (*models.Customer, error) {
+	now := time.Now()
+	customer := &models.Customer{
+		CustomerID: uuid.New().String(),
+		Name:       strings.TrimSpace(req.Name),
+		Email:      strings.ToLower(strings.TrimSpace(req.Email)),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	if err := s.repo.Create(ctx, customer); err != nil {
+		return nil, fmt.Errorf("erro ao salvar o cliente: %w", err)
+	}
+
+	logger.Infof("Cliente %s criado", customer.CustomerID)
+	return customer, nil
+}
+
+func (s *CustomerService) GetCustomer(ctx context.Context, customerID string) (*models.Customer, error) {
+	if err := auth.AuthorizeCustomer(ctx, customerID); err != nil {
+		return nil, err
+	}
+
+	customer, err := s.repo.FindByCustomerID(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar o cliente: %w", err)
+	}
+
+	return customer, nil
+}
@@ -2,115 +2,353 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"sync"
 	"time"
 
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/apperror"
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/auth"
 	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/logger"
 	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/models"
 	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/ports"
+	"github.com/dev-bruno-arruda/api-pedidos/domain"
 	"github.com/google/uuid"
 )
 
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+	maxCancelRetries = 3
+)
+
+type OrderServiceConfig struct {
+	InstanceID             string
+	IdempotencyLockTimeout time.Duration
+	// TaxRateBps é a alíquota aplicada sobre o subtotal com desconto, em pontos-base.
+	TaxRateBps int64
+}
+
 type OrderService struct {
-	repo      ports.OrderRepository
-	publisher ports.MessagePublisher
-	workers   int
-	jobQueue  chan asyncJob
-	wg        sync.WaitGroup
+	repo        ports.OrderRepository
+	customers   ports.CustomerRepository
+	outbox      ports.OutboxRepository
+	idempotency ports.IdempotencyRepository
+	tx          ports.Transactor
+	config      OrderServiceConfig
+	actor       string
 }
 
-type asyncJob struct {
-	ctx     context.Context
-	message models.OrderMessage
+func NewOrderService(repo ports.OrderRepository, customers ports.CustomerRepository, outbox ports.OutboxRepository, idempotency ports.IdempotencyRepository, tx ports.Transactor, config OrderServiceConfig) *OrderService {
+	return &OrderService{
+		repo:        repo,
+		customers:   customers,
+		outbox:      outbox,
+		idempotency: idempotency,
+		tx:          tx,
+		config:      config,
+		actor:       "api:" + config.InstanceID,
+	}
 }
 
-func NewOrderService(repo ports.OrderRepository, publisher ports.MessagePublisher) *OrderService {
-	workers := 10
-	service := &OrderService{
-		repo:      repo,
-		publisher: publisher,
-		workers:   workers,
-		jobQueue:  make(chan asyncJob, 100),
+// CreateOrder cria o pedido. Com idempotencyKey, uma repetição com o mesmo corpo
+// devolve a resposta original (replayed=true) em vez de criar outro pedido.
+func (s *OrderService) CreateOrder(ctx context.Context, req models.CreateOrderRequest, idempotencyKey string) (response *models.CreateOrderResponse, replayed bool, err error) {
+	if err := auth.AuthorizeCustomer(ctx, req.CustomerID); err != nil {
+		return nil, false, err
 	}
 
-	for i := 0; i < workers; i++ {
-		service.wg.Add(1)
-		go service.worker(i)
+	if idempotencyKey == "" {
+		response, err = s.createOrder(ctx, req, "", "")
+		return response, false, err
 	}
 
-	return service
-}
+	// Chaves de chamadores diferentes não colidem: um cliente não recebe a
+	// resposta de outro repetindo a mesma Idempotency-Key.
+	if principal, ok := auth.PrincipalFrom(ctx); ok {
+		idempotencyKey = principal.Subject + "/" + idempotencyKey
+	}
 
-func (s *OrderService) worker(id int) {
-	defer s.wg.Done()
-	logger.WorkerInfo(id, "iniciado")
+	requestHash, err := hashRequest(req)
+	if err != nil {
+		return nil, false, err
+	}
 
-	for job := range s.jobQueue {
-		logger.WorkerInfof(id, "processando job: OrderID=%s", job.message.OrderID)
+	// O token identifica esta requisição como dona da reserva: se o lock
+	// expirar e outra requisição retomar a chave, só uma delas conclui.
+	lockToken := uuid.New().String()
+	existing, err := s.idempotency.Reserve(ctx, idempotencyKey, requestHash, lockToken, time.Now(), s.config.IdempotencyLockTimeout)
+	if err != nil {
+		return nil, false, err
+	}
+	if existing != nil {
+		switch {
+		case existing.RequestHash != requestHash:
+			return nil, false, fmt.Errorf("%w: %s", models.ErrIdempotencyKeyMismatch, idempotencyKey)
+		case existing.Status != models.IdempotencyStatusCompleted || existing.Response == nil:
+			return nil, false, fmt.Errorf("%w: %s", models.ErrIdempotencyKeyInUse, idempotencyKey)
+		}
+		logger.Infof("Idempotency-Key %s repetida, devolvendo pedido %s", idempotencyKey, existing.Response.OrderID)
+		return existing.Response, true, nil
+	}
 
-		err := s.publisher.PublishOrderMessage(job.ctx, job.message)
-		if err != nil {
-			logger.WorkerErrorf(id, "erro ao publicar mensagem para OrderID=%s: %v", job.message.OrderID, err)
-		} else {
-			logger.WorkerInfof(id, "mensagem publicada com sucesso para OrderID=%s", job.message.OrderID)
+	response, err = s.createOrder(ctx, req, idempotencyKey, lockToken)
+	if err != nil {
+		// Libera a chave para que o cliente possa tentar novamente.
+		if releaseErr := s.idempotency.Release(context.WithoutCancel(ctx), idempotencyKey, lockToken); releaseErr != nil {
+			logger.Errorf("Erro ao liberar Idempotency-Key %s: %v", idempotencyKey, releaseErr)
 		}
+		return nil, false, err
 	}
 
-	logger.WorkerInfo(id, "finalizado")
+	return response, false, nil
 }
 
-func (s *OrderService) Shutdown() {
-	close(s.jobQueue)
-	s.wg.Wait()
-	logger.Info("Todos os workers foram encerrados")
-}
+func (s *OrderService) createOrder(ctx context.Context, req models.CreateOrderRequest, idempotencyKey, lockToken string) (*models.CreateOrderResponse, error) {
+	if _, err := s.customers.FindByCustomerID(ctx, req.CustomerID); err != nil {
+		if errors.Is(err, models.ErrCustomerNotFound) {
+			return nil, fmt.Errorf("%w: %s", models.ErrUnknownCustomer, req.CustomerID)
+		}
+		return nil, fmt.Errorf("erro ao buscar o cliente: %w", err)
+	}
+
+	items := make([]domain.OrderItem, 0, len(req.Items))
+	for i, item := range req.Items {
+		unitPrice, err := domain.ParseMoney(item.UnitPrice.String(), item.Currency)
+		if err != nil {
+			return nil, domainError(fmt.Errorf("item %d: %w", i, err))
+		}
+		orderItem, err := domain.NewOrderItem(item.SKU, item.Name, item.Quantity, unitPrice)
+		if err != nil {
+			return nil, domainError(fmt.Errorf("item %d: %w", i, err))
+		}
+		items = append(items, orderItem)
+	}
+
+	totals, err := domain.CalculateTotals(items, req.DiscountBps, s.config.TaxRateBps)
+	if err != nil {
+		return nil, domainError(fmt.Errorf("erro ao calcular totais do pedido: %w", err))
+	}
 
-func (s *OrderService) CreateOrder(ctx context.Context, req models.CreateOrderRequest) (*models.CreateOrderResponse, error) {
 	orderID := uuid.New().String()
 
 	now := time.Now()
 	order := &models.Order{
+		OrderID:    orderID,
+		CustomerID: req.CustomerID,
+		CreatedBy:  subject(ctx),
+		Items:      items,
+		Totals:     totals,
+		Status:     domain.StatusCriado,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		StatusHistory: []domain.StatusHistoryEntry{
+			domain.StatusChange{To: domain.StatusCriado, Actor: s.actor, Reason: "pedido criado"}.Entry(now),
+		},
+	}
+
+	message := models.OrderMessage{
+		MessageID: uuid.New().String(),
 		OrderID:   orderID,
-		Product:   req.Product,
-		Quantity:  req.Quantity,
-		Status:    models.StatusCriado,
-		CreatedAt: now,
-		UpdatedAt: now,
+		Status:    domain.StatusProcessando,
 	}
 
-	err := s.repo.Create(ctx, order)
+	response := &models.CreateOrderResponse{
+		OrderID: orderID,
+		Status:  domain.StatusCriado,
+		Totals:  totals,
+	}
+
+	// O pedido e a mensagem do outbox são gravados na mesma transação; o
+	// OutboxRelay publica a mensagem mesmo que o processo caia logo em seguida.
+	// A resposta da Idempotency-Key também, para não existir pedido sem registro.
+	err = s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, order); err != nil {
+			return err
+		}
+		if err := s.outbox.Add(ctx, models.NewOutboxEntry(message, now)); err != nil {
+			return err
+		}
+		if idempotencyKey != "" {
+			return s.idempotency.Complete(ctx, idempotencyKey, lockToken, response)
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("erro ao salvar o pedido: %w", err)
 	}
 
-	message := models.OrderMessage{
-		OrderID: orderID,
-		Status:  models.StatusProcessando,
+	logger.Infof("Pedido %s salvo com mensagem pendente no outbox", orderID)
+
+	return response, nil
+}
+
+// subject é o chamador autenticado, vazio com a autenticação desabilitada.
+func subject(ctx context.Context) string {
+	if principal, ok := auth.PrincipalFrom(ctx); ok {
+		return principal.Subject
+	}
+	return ""
+}
+
+func hashRequest(req models.CreateOrderRequest) (string, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("erro ao calcular hash da requisição: %w", err)
 	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (s *OrderService) GetOrder(ctx context.Context, orderID string) (*models.Order, error) {
+	order, err := s.repo.FindByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar o pedido: %w", err)
+	}
+	if err := auth.AuthorizeCustomer(ctx, order.CustomerID); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
 
-	workerCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+// ListOrders lista pedidos de todos os clientes apenas para administradores;
+// para os demais chamadores a lista fica restrita ao próprio cliente.
+func (s *OrderService) ListOrders(ctx context.Context, filter models.OrderFilter) (*models.ListOrdersResponse, error) {
+	if principal, ok := auth.PrincipalFrom(ctx); ok && !principal.IsAdmin() && filter.CustomerID == "" {
+		filter.CustomerID = principal.CustomerID
+	}
+	if err := auth.AuthorizeCustomer(ctx, filter.CustomerID); err != nil {
+		return nil, err
+	}
 
-	job := asyncJob{
-		ctx:     workerCtx,
-		message: message,
+	if filter.Limit <= 0 {
+		filter.Limit = defaultListLimit
 	}
-	select {
-	case s.jobQueue <- job:
-		logger.Infof("Job enfileirado para OrderID=%s", orderID)
-	default:
-		logger.Warnf("Fila de jobs cheia, job para OrderID=%s pode ser processado com atraso", orderID)
-		s.jobQueue <- job
+	if filter.Limit > maxListLimit {
+		filter.Limit = maxListLimit
 	}
 
-	go func() { //Li que dependendo da versão do go, esse cancelamento é redundante(pelo próprio timeout do contexto), porém, deixei aqui para exemplificar
-		//eu poderia usar defer para isso também. Mais uma vez, fiz dessa forma apenas para mostrar que também pode ser feito assim.
-		<-workerCtx.Done()
-		cancel()
-	}()
+	page, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar pedidos: %w", err)
+	}
 
-	return &models.CreateOrderResponse{
-		OrderID: orderID,
-		Status:  models.StatusCriado,
+	return &models.ListOrdersResponse{
+		Data:       page.Orders,
+		NextCursor: page.NextCursor,
+		Total:      page.Total,
 	}, nil
 }
+
+// ListCustomerOrders lista os pedidos do cliente com os mesmos filtros de
+// ListOrders. Clientes inexistentes retornam models.ErrCustomerNotFound.
+func (s *OrderService) ListCustomerOrders(ctx context.Context, customerID string, filter models.OrderFilter) (*models.ListOrdersResponse, error) {
+	if err := auth.AuthorizeCustomer(ctx, customerID); err != nil {
+		return nil, err
+	}
+	if _, err := s.customers.FindByCustomerID(ctx, customerID); err != nil {
+		return nil, fmt.Errorf("erro ao buscar o cliente: %w", err)
+	}
+
+	filter.CustomerID = customerID
+	return s.ListOrders(ctx, filter)
+}
+
+func (s *OrderService) GetOrderHistory(ctx context.Context, orderID string) (*models.OrderHistoryResponse, error) {
+	order, err := s.repo.FindByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar o pedido: %w", err)
+	}
+	if err := auth.AuthorizeCustomer(ctx, order.CustomerID); err != nil {
+		return nil, err
+	}
+
+	history := order.StatusHistory
+	if history == nil {
+		history = []domain.StatusHistoryEntry{}
+	}
+
+	return &models.OrderHistoryResponse{
+		OrderID: order.OrderID,
+		Status:  order.Status,
+		History: history,
+	}, nil
+}
+
+func (s *OrderService) CancelOrder(ctx context.Context, orderID string, reason string) (*models.CancelOrderResponse, error) {
+	if reason == "" {
+		reason = "cancelado via API"
+	}
+
+	for attempt := 1; ; attempt++ {
+		order, err := s.repo.FindByOrderID(ctx, orderID)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao buscar o pedido: %w", err)
+		}
+
+		if err := domain.ValidateTransition(order.Status, domain.StatusCancelado); err != nil {
+			return nil, domainError(fmt.Errorf("pedido não pode ser cancelado: %w", err))
+		}
+
+		// A mensagem CANCELADO faz o worker liberar o estoque reservado; ela é
+		// gravada na mesma transação que o novo status.
+		message := models.OrderMessage{
+			MessageID: uuid.New().String(),
+			OrderID:   orderID,
+			Status:    domain.StatusCancelado,
+		}
+		err = s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+			err := s.repo.UpdateStatus(ctx, orderID, domain.StatusChange{
+				From:   order.Status,
+				To:     domain.StatusCancelado,
+				Actor:  s.actor,
+				Reason: reason,
+			})
+			if err != nil {
+				return err
+			}
+			return s.outbox.Add(ctx, models.NewOutboxEntry(message, time.Now()))
+		})
+		if err == nil {
+			logger.Infof("Pedido %s cancelado (status anterior: %s)", orderID, order.Status)
+			return &models.CancelOrderResponse{
+				OrderID:        orderID,
+				Status:         domain.StatusCancelado,
+				PreviousStatus: order.Status,
+			}, nil
+		}
+
+		if !errors.Is(err, domain.ErrInvalidTransition) || attempt >= maxCancelRetries {
+			return nil, domainError(fmt.Errorf("erro ao cancelar o pedido: %w", err))
+		}
+		logger.Warnf("Status do pedido %s mudou durante o cancelamento, tentando novamente", orderID)
+	}
+}
+
+// domainError converte os erros do pacote domain, compartilhado com o worker,
+// em erros tipados da API. Outros erros são devolvidos sem alteração.
+func domainError(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidTransition):
+		return &apperror.Error{
+			Kind:    apperror.KindConflict,
+			Code:    "invalid_status_transition",
+			Message: "Transição de status não permitida no status atual do pedido",
+			Err:     err,
+		}
+	case errors.Is(err, domain.ErrCurrencyMismatch),
+		errors.Is(err, domain.ErrUnknownCurrency),
+		errors.Is(err, domain.ErrInvalidAmount),
+		errors.Is(err, domain.ErrAmountOverflow):
+		return &apperror.Error{
+			Kind:    apperror.KindValidation,
+			Code:    "invalid_amount",
+			Message: "Valores monetários do pedido inválidos",
+			Err:     err,
+		}
+	}
+	return err
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/logger"
+	"github.com/dev-bruno-arruda/api-pedidos/api_service/pgk/ports"
+)
+
+type OutboxRelayConfig struct {
+	Workers        int
+	PollInterval   time.Duration
+	LeaseTimeout   time.Duration
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+}
+
+// OutboxRelay publica no broker as mensagens gravadas no outbox junto com os
+// pedidos. Entradas que falham voltam a ficar pendentes com backoff exponencial,
+// e entradas reservadas por uma instância que caiu são retomadas quando o lease expira.
+type OutboxRelay struct {
+	outbox    ports.OutboxRepository
+	publisher ports.MessagePublisher
+	config    OutboxRelayConfig
+	stop      chan struct{}
+	wg        sync.WaitGroup
+}
+
+func NewOutboxRelay(outbox ports.OutboxRepository, publisher ports.MessagePublisher, config OutboxRelayConfig) *OutboxRelay {
+	return &OutboxRelay{
+		outbox:    outbox,
+		publisher: publisher,
+		config:    config,
+		stop:      make(chan struct{}),
+	}
+}
+
+func (r *OutboxRelay) Start() {
+	for i := 0; i < r.config.Workers; i++ {
+		r.wg.Add(1)
+		go r.worker(i)
+	}
+}
+
+func (r *OutboxRelay) Shutdown() {
+	close(r.stop)
+	r.wg.Wait()
+	logger.Info("Todos os workers do outbox foram encerrados")
+}
+
+func (r *OutboxRelay) worker(id int) {
+	defer r.wg.Done()
+	logger.WorkerInfo(id, "outbox iniciado")
+
+	ticker := time.NewTicker(r.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Esvazia as pendências antes de voltar a esperar pelo próximo tick.
+		for r.relayNext(id) {
+			select {
+			case <-r.stop:
+				logger.WorkerInfo(id, "outbox finalizado")
+				return
+			default:
+			}
+		}
+
+		select {
+		case <-r.stop:
+			logger.WorkerInfo(id, "outbox finalizado")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *OutboxRelay) relayNext(id int) bool {
+	ctx := context.Background()
+
+	entry, err := r.outbox.ClaimNext(ctx, time.Now(), r.config.LeaseTimeout)
+	if err != nil {
+		logger.WorkerErrorf(id, "erro ao buscar mensagens pendentes no outbox: %v", err)
+		return false
+	}
+	if entry == nil {
+		return false
+	}
+
+	logger.WorkerInfof(id, "publicando mensagem do outbox: OrderID=%s, tentativa %d", entry.OrderID, entry.Attempts)
+
+	err = r.publisher.PublishOrderMessage(ctx, entry.Message)
+	if err != nil {
+		nextAttemptAt := time.Now().Add(r.backoff(entry.Attempts))
+
+		var publishErr *ports.PublishError
+		if errors.As(err, &publishErr) {
+			logger.WorkerErrorf(id, "broker não aceitou a mensagem para OrderID=%s, nova tentativa em %s: %v",
+				entry.OrderID, nextAttemptAt.Format(time.RFC3339), publishErr)
+		} else {
+			logger.WorkerErrorf(id, "erro ao publicar mensagem para OrderID=%s, nova tentativa em %s: %v",
+				entry.OrderID, nextAttemptAt.Format(time.RFC3339), err)
+		}
+
+		if markErr := r.outbox.MarkFailed(ctx, entry.ID, nextAttemptAt, err.Error()); markErr != nil {
+			logger.WorkerErrorf(id, "erro ao registrar falha do outbox para OrderID=%s: %v", entry.OrderID, markErr)
+		}
+		return true
+	}
+
+	if err := r.outbox.MarkSent(ctx, entry.ID); err != nil {
+		// A mensagem será publicada novamente quando o lease expirar; o worker
+		// trata mensagens repetidas pelo status do pedido.
+		logger.WorkerErrorf(id, "erro ao marcar mensagem do outbox como enviada para OrderID=%s: %v", entry.OrderID, err)
+		return true
+	}
+
+	logger.WorkerInfof(id, "mensagem publicada com sucesso para OrderID=%s", entry.OrderID)
+	return true
+}
+
+func (r *OutboxRelay) backoff(attempts int) time.Duration {
+	delay := r.config.RetryBaseDelay
+	for i := 1; i < attempts && delay < r.config.RetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > r.config.RetryMaxDelay {
+		delay = r.config.RetryMaxDelay
+	}
+	return delay
+}
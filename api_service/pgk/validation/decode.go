@@ -0,0 +1,166 @@
+package validation
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+var (
+	ErrBodyTooLarge = errors.New("corpo da requisição maior que o permitido")
+	ErrEmptyBody    = errors.New("corpo da requisição vazio")
+)
+
+// DecodeJSON lê um único objeto JSON do corpo, limitado a maxBytes e rejeitando
+// campos desconhecidos. Erros de sintaxe, tipo e campos desconhecidos viram um
+// *Error com o ponteiro do campo; corpo grande demais retorna ErrBodyTooLarge e
+// corpo vazio, ErrEmptyBody.
+func DecodeJSON(w http.ResponseWriter, r *http.Request, dst any, maxBytes int64) error {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBytes))
+	if err != nil {
+		return decodeError(data, err, maxBytes)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(dst); err != nil {
+		return decodeError(data, err, maxBytes)
+	}
+
+	if err := decoder.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		if err != nil {
+			return decodeError(data, err, maxBytes)
+		}
+		return &Error{Violations: []Violation{{Code: CodeInvalidJSON, Detail: "o corpo deve conter um único objeto JSON"}}}
+	}
+
+	return nil
+}
+
+func decodeError(data []byte, err error, maxBytes int64) error {
+	var maxBytesErr *http.MaxBytesError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return fmt.Errorf("%w: limite de %d bytes", ErrBodyTooLarge, maxBytes)
+
+	case errors.Is(err, io.EOF):
+		return ErrEmptyBody
+
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return &Error{Violations: []Violation{{Code: CodeInvalidJSON, Detail: "JSON inválido"}}}
+
+	case errors.As(err, &typeErr):
+		return &Error{Violations: []Violation{{
+			Pointer: typeErrorPointer(data, typeErr),
+			Code:    CodeInvalidType,
+			Detail:  fmt.Sprintf("tipo inválido, esperado %s", typeErr.Type),
+		}}}
+
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json não exporta um tipo para esse erro.
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return &Error{Violations: []Violation{{
+			Pointer: Pointer(field),
+			Code:    CodeUnknownField,
+			Detail:  "campo desconhecido",
+		}}}
+	}
+
+	return &Error{Violations: []Violation{{Code: CodeInvalidJSON, Detail: "JSON inválido"}}}
+}
+
+// typeErrorPointer monta o ponteiro do valor que causou o erro. O Field do
+// encoding/json não traz os índices dos arrays ("items.quantity"), então o
+// caminho é refeito percorrendo o corpo até o Offset do erro.
+func typeErrorPointer(data []byte, typeErr *json.UnmarshalTypeError) string {
+	if typeErr.Offset > 0 {
+		if segments, ok := pathAt(data, typeErr.Offset); ok {
+			return Pointer(segments...)
+		}
+	}
+	return fieldPointer(typeErr.Field)
+}
+
+type pathFrame struct {
+	array bool
+	index int
+	key   string
+}
+
+// pathAt devolve o caminho do último valor que começa antes de offset, que é o
+// valor onde o decoder parou: o fim de um literal ou o início de um objeto ou
+// array.
+func pathAt(data []byte, offset int64) ([]any, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var stack []*pathFrame
+	var found []any
+	ok := false
+	expectKey := false
+
+	for decoder.InputOffset() < offset {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+
+		if delim, isDelim := token.(json.Delim); isDelim && (delim == '}' || delim == ']') {
+			stack = stack[:len(stack)-1]
+			expectKey = len(stack) > 0 && !stack[len(stack)-1].array
+			continue
+		}
+
+		if expectKey {
+			stack[len(stack)-1].key = token.(string)
+			expectKey = false
+			continue
+		}
+
+		if len(stack) > 0 {
+			if parent := stack[len(stack)-1]; parent.array {
+				parent.index++
+			} else {
+				expectKey = true
+			}
+		}
+
+		found = make([]any, len(stack))
+		for i, frame := range stack {
+			if frame.array {
+				found[i] = frame.index
+			} else {
+				found[i] = frame.key
+			}
+		}
+		ok = true
+
+		if delim, isDelim := token.(json.Delim); isDelim {
+			stack = append(stack, &pathFrame{array: delim == '[', index: -1})
+			expectKey = delim == '{'
+		}
+	}
+
+	return found, ok
+}
+
+// fieldPointer converte o caminho "items.quantity" do encoding/json em ponteiro.
+func fieldPointer(field string) string {
+	if field == "" {
+		return ""
+	}
+	parts := strings.Split(field, ".")
+	segments := make([]any, len(parts))
+	for i, part := range parts {
+		segments[i] = part
+	}
+	return Pointer(segments...)
+}
@@ -0,0 +1,106 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testItem struct {
+	SKU      string `json:"sku"`
+	Quantity int    `json:"quantity"`
+}
+
+type testRequest struct {
+	CustomerID string     `json:"customer_id"`
+	Items      []testItem `json:"items"`
+}
+
+func decode(t *testing.T, body string) error {
+	t.Helper()
+	r := httptest.NewRequest("POST", "/orders", strings.NewReader(body))
+	var dst testRequest
+	return DecodeJSON(httptest.NewRecorder(), r, &dst, 1<<10)
+}
+
+func TestDecodeJSONTypeErrorPointer(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		pointer string
+	}{
+		{name: "campo raiz", body: `{"customer_id": 5}`, pointer: "/customer_id"},
+		{name: "array com tipo errado", body: `{"items": "x"}`, pointer: "/items"},
+		{name: "terceiro item", body: `{"items": [{"quantity": 1}, {"quantity": 2}, {"quantity": "x"}]}`, pointer: "/items/2/quantity"},
+		{name: "objeto no lugar de número", body: `{"items": [{"sku": "A"}, {"quantity": {"a": 1}}]}`, pointer: "/items/1/quantity"},
+		{name: "depois de campo aninhado", body: `{"items": [{"sku": "A", "quantity": 1}], "customer_id": []}`, pointer: "/customer_id"},
+		{name: "fracao em inteiro", body: `{"items": [{"quantity": 1}, {"quantity": 1.5}]}`, pointer: "/items/1/quantity"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var validationErr *Error
+			if err := decode(t, tt.body); !errors.As(err, &validationErr) {
+				t.Fatalf("erro = %v, esperado *Error", err)
+			}
+			v := validationErr.Violations[0]
+			if v.Code != CodeInvalidType || v.Pointer != tt.pointer {
+				t.Fatalf("violação = %s %s, esperado %s %s", v.Code, v.Pointer, CodeInvalidType, tt.pointer)
+			}
+		})
+	}
+}
+
+// O encoding/json do Go 1.24 preenche Field sem os índices; o ponteiro vem do
+// Offset.
+func TestTypeErrorPointerUsesOffset(t *testing.T) {
+	data := []byte(`{"items": [{"quantity": 1}, {"quantity": 2}, {"quantity": "x"}]}`)
+	offset := int64(strings.Index(string(data), `"x"`) + len(`"x"`))
+
+	got := typeErrorPointer(data, &json.UnmarshalTypeError{Field: "items.quantity", Offset: offset})
+	if got != "/items/2/quantity" {
+		t.Fatalf("ponteiro = %q, esperado /items/2/quantity", got)
+	}
+}
+
+func TestPathAt(t *testing.T) {
+	data := []byte(`{"a": [10, {"b": [true, "x/y"]}], "c~d": null}`)
+
+	tests := []struct {
+		after string
+		want  []any
+	}{
+		{after: `10`, want: []any{"a", 0}},
+		{after: `true`, want: []any{"a", 1, "b", 0}},
+		{after: `"x/y"`, want: []any{"a", 1, "b", 1}},
+		{after: `null`, want: []any{"c~d"}},
+	}
+
+	for _, tt := range tests {
+		offset := int64(strings.Index(string(data), tt.after) + len(tt.after))
+		got, ok := pathAt(data, offset)
+		if !ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("pathAt depois de %s = %v, esperado %v", tt.after, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeJSONErrors(t *testing.T) {
+	if err := decode(t, ""); !errors.Is(err, ErrEmptyBody) {
+		t.Errorf("corpo vazio: erro = %v, esperado ErrEmptyBody", err)
+	}
+	if err := decode(t, `{"customer_id": "`+strings.Repeat("a", 2<<10)+`"}`); !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("corpo grande: erro = %v, esperado ErrBodyTooLarge", err)
+	}
+
+	var validationErr *Error
+	if err := decode(t, `{"customer_id": "1"} {}`); !errors.As(err, &validationErr) || validationErr.Violations[0].Code != CodeInvalidJSON {
+		t.Errorf("dois objetos: erro = %v, esperado %s", err, CodeInvalidJSON)
+	}
+	if err := decode(t, `{"other": 1}`); !errors.As(err, &validationErr) || validationErr.Violations[0].Pointer != "/other" {
+		t.Errorf("campo desconhecido: erro = %v, esperado /other", err)
+	}
+}
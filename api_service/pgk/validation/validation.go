@@ -0,0 +1,129 @@
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Códigos estáveis das violações, para os clientes não dependerem das mensagens.
+const (
+	CodeRequired     = "required"
+	CodeLength       = "length"
+	CodeRange        = "range"
+	CodeInvalidChars = "invalid_characters"
+	CodeInvalidValue = "invalid_value"
+	CodeInvalidType  = "invalid_type"
+	CodeUnknownField = "unknown_field"
+	CodeInvalidJSON  = "invalid_json"
+)
+
+// Violation descreve um campo inválido. Pointer é um JSON Pointer (RFC 6901)
+// para o campo no corpo; violações de headers e query params usam Header e
+// Parameter.
+type Violation struct {
+	Pointer   string `json:"pointer,omitempty"`
+	Header    string `json:"header,omitempty"`
+	Parameter string `json:"parameter,omitempty"`
+	Code      string `json:"code"`
+	Detail    string `json:"detail"`
+}
+
+// Error agrega todas as violações encontradas em uma requisição.
+type Error struct {
+	Violations []Violation
+}
+
+func (e *Error) Error() string {
+	details := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		field := v.Pointer
+		if v.Header != "" {
+			field = v.Header
+		}
+		if v.Parameter != "" {
+			field = v.Parameter
+		}
+		details = append(details, fmt.Sprintf("%s: %s", field, v.Detail))
+	}
+	return "requisição inválida: " + strings.Join(details, "; ")
+}
+
+// Validator acumula violações em vez de parar na primeira.
+type Validator struct {
+	violations []Violation
+}
+
+func (v *Validator) Add(pointer, code, detail string) {
+	v.violations = append(v.violations, Violation{Pointer: pointer, Code: code, Detail: detail})
+}
+
+func (v *Validator) AddHeader(header, code, detail string) {
+	v.violations = append(v.violations, Violation{Header: header, Code: code, Detail: detail})
+}
+
+func (v *Validator) AddParameter(parameter, code, detail string) {
+	v.violations = append(v.violations, Violation{Parameter: parameter, Code: code, Detail: detail})
+}
+
+// Check registra a violação quando ok é falso e devolve ok, para permitir
+// pular regras que dependem da anterior.
+func (v *Validator) Check(ok bool, pointer, code, detail string) bool {
+	if !ok {
+		v.Add(pointer, code, detail)
+	}
+	return ok
+}
+
+func (v *Validator) Required(pointer, value string) bool {
+	return v.Check(strings.TrimSpace(value) != "", pointer, CodeRequired, "campo obrigatório")
+}
+
+// Length valida o tamanho em caracteres (runes), não em bytes.
+func (v *Validator) Length(pointer, value string, min, max int) bool {
+	n := utf8.RuneCountInString(value)
+	return v.Check(n >= min && n <= max, pointer, CodeLength,
+		fmt.Sprintf("deve ter entre %d e %d caracteres", min, max))
+}
+
+func (v *Validator) Range(pointer string, value, min, max int64) bool {
+	return v.Check(value >= min && value <= max, pointer, CodeRange,
+		fmt.Sprintf("deve estar entre %d e %d", min, max))
+}
+
+func (v *Validator) Matches(pointer, value string, pattern *regexp.Regexp, description string) bool {
+	return v.Check(pattern.MatchString(value), pointer, CodeInvalidChars, description)
+}
+
+// Printable rejeita caracteres de controle (quebras de linha, tabulação, NUL...).
+func (v *Validator) Printable(pointer, value string) bool {
+	ok := utf8.ValidString(value) && strings.IndexFunc(value, unicode.IsControl) < 0
+	return v.Check(ok, pointer, CodeInvalidChars, "contém caracteres não permitidos")
+}
+
+func (v *Validator) Valid() bool {
+	return len(v.violations) == 0
+}
+
+// Err retorna um *Error com todas as violações, ou nil se não houver nenhuma.
+func (v *Validator) Err() error {
+	if v.Valid() {
+		return nil
+	}
+	return &Error{Violations: v.violations}
+}
+
+// Pointer monta um JSON Pointer a partir dos segmentos, escapando "~" e "/".
+func Pointer(segments ...any) string {
+	var b strings.Builder
+	for _, segment := range segments {
+		s := fmt.Sprint(segment)
+		s = strings.ReplaceAll(s, "~", "~0")
+		s = strings.ReplaceAll(s, "/", "~1")
+		b.WriteString("/")
+		b.WriteString(s)
+	}
+	return b.String()
+}
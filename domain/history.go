@@ -0,0 +1,34 @@
+package domain
+
+import "time"
+
+// StatusChange descreve uma transição solicitada por um serviço, junto com
+// quem a executou e por quê. Os repositórios gravam cada uma como uma entrada
+// do histórico do pedido na mesma operação que altera o status.
+type StatusChange struct {
+	From       Status
+	To         Status
+	Actor      string
+	Reason     string
+	DeliveryID string
+}
+
+type StatusHistoryEntry struct {
+	From       Status    `json:"from,omitempty" bson:"from,omitempty"`
+	To         Status    `json:"to" bson:"to"`
+	At         time.Time `json:"at" bson:"at"`
+	Actor      string    `json:"actor" bson:"actor"`
+	Reason     string    `json:"reason,omitempty" bson:"reason,omitempty"`
+	DeliveryID string    `json:"delivery_id,omitempty" bson:"delivery_id,omitempty"`
+}
+
+func (c StatusChange) Entry(at time.Time) StatusHistoryEntry {
+	return StatusHistoryEntry{
+		From:       c.From,
+		To:         c.To,
+		At:         at,
+		Actor:      c.Actor,
+		Reason:     c.Reason,
+		DeliveryID: c.DeliveryID,
+	}
+}
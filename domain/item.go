@@ -0,0 +1,84 @@
+package domain
+
+// OrderItem é uma linha do pedido. Total é calculado pelo servidor
+// (Quantity * UnitPrice) e nunca aceito do cliente.
+type OrderItem struct {
+	SKU       string `json:"sku" bson:"sku"`
+	Name      string `json:"name" bson:"name"`
+	Quantity  int    `json:"quantity" bson:"quantity"`
+	UnitPrice Money  `json:"unit_price" bson:"unit_price"`
+	Total     Money  `json:"total" bson:"total"`
+}
+
+func NewOrderItem(sku, name string, quantity int, unitPrice Money) (OrderItem, error) {
+	total, err := unitPrice.Mul(int64(quantity))
+	if err != nil {
+		return OrderItem{}, err
+	}
+	return OrderItem{
+		SKU:       sku,
+		Name:      name,
+		Quantity:  quantity,
+		UnitPrice: unitPrice,
+		Total:     total,
+	}, nil
+}
+
+// LegacyOrderItem representa os pedidos gravados antes dos itens, que tinham
+// apenas product e quantity, sem preço.
+func LegacyOrderItem(product string, quantity int) OrderItem {
+	return OrderItem{
+		Name:     product,
+		Quantity: quantity,
+	}
+}
+
+// OrderTotals resume os valores do pedido. O desconto incide sobre o subtotal e
+// o imposto sobre o subtotal já com desconto.
+type OrderTotals struct {
+	Subtotal Money `json:"subtotal" bson:"subtotal"`
+	Discount Money `json:"discount" bson:"discount"`
+	Tax      Money `json:"tax" bson:"tax"`
+	Total    Money `json:"total" bson:"total"`
+}
+
+// CalculateTotals soma os itens e aplica desconto e imposto em pontos-base
+// (1000 = 10%). Itens com moedas diferentes retornam ErrCurrencyMismatch.
+func CalculateTotals(items []OrderItem, discountBps, taxBps int64) (OrderTotals, error) {
+	if len(items) == 0 {
+		return OrderTotals{}, ErrInvalidAmount
+	}
+
+	subtotal := Zero(items[0].Total.Currency())
+	for _, item := range items {
+		var err error
+		subtotal, err = subtotal.Add(item.Total)
+		if err != nil {
+			return OrderTotals{}, err
+		}
+	}
+
+	discount, err := subtotal.Percent(discountBps)
+	if err != nil {
+		return OrderTotals{}, err
+	}
+	taxable, err := subtotal.Sub(discount)
+	if err != nil {
+		return OrderTotals{}, err
+	}
+	tax, err := taxable.Percent(taxBps)
+	if err != nil {
+		return OrderTotals{}, err
+	}
+	total, err := taxable.Add(tax)
+	if err != nil {
+		return OrderTotals{}, err
+	}
+
+	return OrderTotals{
+		Subtotal: subtotal,
+		Discount: discount,
+		Tax:      tax,
+		Total:    total,
+	}, nil
+}
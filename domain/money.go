@@ -0,0 +1,240 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+var (
+	ErrCurrencyMismatch = errors.New("valores com moedas diferentes")
+	ErrUnknownCurrency  = errors.New("moeda não suportada")
+	ErrInvalidAmount    = errors.New("valor monetário inválido")
+	ErrAmountOverflow   = errors.New("valor monetário fora do intervalo suportado")
+)
+
+// Casas decimais (minor units) de cada moeda ISO 4217 aceita.
+var currencyExponents = map[string]int{
+	"ARS": 2, "BRL": 2, "CAD": 2, "CHF": 2, "CLP": 0, "CNY": 2, "COP": 2,
+	"EUR": 2, "GBP": 2, "JPY": 0, "KRW": 0, "MXN": 2, "PEN": 2, "PYG": 0,
+	"USD": 2, "UYU": 2, "BHD": 3, "KWD": 3,
+}
+
+func IsSupportedCurrency(currency string) bool {
+	_, ok := currencyExponents[currency]
+	return ok
+}
+
+// Money é um valor monetário exato: um inteiro em unidades mínimas da moeda
+// (centavos para BRL) e o código ISO 4217. Nunca passa por float64.
+//
+// No MongoDB é gravado como {amount: <int64 em unidades mínimas>, currency}; em
+// JSON como {"amount": "<decimal>", "currency"}, com o valor em string para que
+// clientes não percam precisão.
+type Money struct {
+	amount   int64
+	currency string
+}
+
+func NewMoney(minorUnits int64, currency string) (Money, error) {
+	if !IsSupportedCurrency(currency) {
+		return Money{}, fmt.Errorf("%w: %q", ErrUnknownCurrency, currency)
+	}
+	return Money{amount: minorUnits, currency: currency}, nil
+}
+
+// ParseMoney converte um decimal ("4599.90") para Money. Valores com mais casas
+// decimais do que a moeda permite são rejeitados em vez de arredondados.
+func ParseMoney(value, currency string) (Money, error) {
+	exponent, ok := currencyExponents[currency]
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %q", ErrUnknownCurrency, currency)
+	}
+
+	value = strings.TrimSpace(value)
+	negative := strings.HasPrefix(value, "-")
+	digits := strings.TrimPrefix(value, "-")
+
+	integer, fraction, _ := strings.Cut(digits, ".")
+	if integer == "" || !isDigits(integer) || !isDigits(fraction) || (strings.Contains(digits, ".") && fraction == "") {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+	}
+	if len(fraction) > exponent {
+		if strings.Trim(fraction[exponent:], "0") != "" {
+			return Money{}, fmt.Errorf("%w: %q tem mais de %d casas decimais para %s", ErrInvalidAmount, value, exponent, currency)
+		}
+		fraction = fraction[:exponent]
+	}
+	fraction += strings.Repeat("0", exponent-len(fraction))
+
+	amount, ok := new(big.Int).SetString(integer+fraction, 10)
+	if !ok || !amount.IsInt64() {
+		return Money{}, fmt.Errorf("%w: %q", ErrAmountOverflow, value)
+	}
+
+	minor := amount.Int64()
+	if negative {
+		minor = -minor
+	}
+	return Money{amount: minor, currency: currency}, nil
+}
+
+func MustParseMoney(value, currency string) Money {
+	m, err := ParseMoney(value, currency)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func Zero(currency string) Money {
+	return Money{currency: currency}
+}
+
+func (m Money) MinorUnits() int64 { return m.amount }
+func (m Money) Currency() string  { return m.currency }
+func (m Money) IsZero() bool      { return m.amount == 0 }
+func (m Money) IsNegative() bool  { return m.amount < 0 }
+
+func (m Money) Add(other Money) (Money, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return Money{}, err
+	}
+	sum := m.amount + other.amount
+	if (other.amount > 0 && sum < m.amount) || (other.amount < 0 && sum > m.amount) {
+		return Money{}, ErrAmountOverflow
+	}
+	return Money{amount: sum, currency: m.currency}, nil
+}
+
+func (m Money) Sub(other Money) (Money, error) {
+	if other.amount == math.MinInt64 {
+		return Money{}, ErrAmountOverflow
+	}
+	return m.Add(Money{amount: -other.amount, currency: other.currency})
+}
+
+func (m Money) Mul(quantity int64) (Money, error) {
+	product := new(big.Int).Mul(big.NewInt(m.amount), big.NewInt(quantity))
+	if !product.IsInt64() {
+		return Money{}, ErrAmountOverflow
+	}
+	return Money{amount: product.Int64(), currency: m.currency}, nil
+}
+
+// Percent calcula bps pontos-base do valor (1000 = 10%), arredondando para a
+// unidade mínima mais próxima com empate para o par (arredondamento bancário).
+func (m Money) Percent(bps int64) (Money, error) {
+	numerator := new(big.Int).Mul(big.NewInt(m.amount), big.NewInt(bps))
+	result := roundHalfEven(numerator, big.NewInt(10000))
+	if !result.IsInt64() {
+		return Money{}, ErrAmountOverflow
+	}
+	return Money{amount: result.Int64(), currency: m.currency}, nil
+}
+
+func (m Money) sameCurrency(other Money) error {
+	if m.currency != other.currency {
+		return fmt.Errorf("%w: %s e %s", ErrCurrencyMismatch, m.currency, other.currency)
+	}
+	return nil
+}
+
+// String formata o valor como decimal, sem a moeda ("4599.90").
+func (m Money) String() string {
+	exponent := currencyExponents[m.currency]
+
+	sign := ""
+	amount := new(big.Int).SetInt64(m.amount)
+	if amount.Sign() < 0 {
+		sign = "-"
+		amount.Neg(amount)
+	}
+
+	digits := amount.String()
+	if exponent == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exponent] + "." + digits[len(digits)-exponent:]
+}
+
+type moneyJSON struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.String(), Currency: m.currency})
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var value moneyJSON
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	parsed, err := ParseMoney(value.Amount, value.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+type moneyBSON struct {
+	Amount   int64  `bson:"amount"`
+	Currency string `bson:"currency"`
+}
+
+func (m Money) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return bson.MarshalValue(moneyBSON{Amount: m.amount, Currency: m.currency})
+}
+
+func (m *Money) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	if t == bsontype.Null || t == bsontype.Undefined {
+		*m = Money{}
+		return nil
+	}
+
+	var value moneyBSON
+	if err := bson.UnmarshalValue(t, data, &value); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAmount, err)
+	}
+	*m = Money{amount: value.Amount, currency: value.Currency}
+	return nil
+}
+
+func roundHalfEven(numerator, denominator *big.Int) *big.Int {
+	quotient, remainder := new(big.Int).QuoRem(numerator, denominator, new(big.Int))
+	if remainder.Sign() == 0 {
+		return quotient
+	}
+
+	// Compara 2*|resto| com o divisor para decidir o arredondamento.
+	twice := new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2))
+	cmp := twice.Cmp(new(big.Int).Abs(denominator))
+	if cmp > 0 || (cmp == 0 && quotient.Bit(0) == 1) {
+		if numerator.Sign()*denominator.Sign() < 0 {
+			return quotient.Sub(quotient, big.NewInt(1))
+		}
+		return quotient.Add(quotient, big.NewInt(1))
+	}
+	return quotient
+}
+
+func isDigits(value string) bool {
+	for _, c := range value {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
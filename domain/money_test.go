@@ -0,0 +1,238 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		currency string
+		want     int64
+		wantErr  error
+	}{
+		{name: "decimal", value: "4599.90", currency: "BRL", want: 459990},
+		{name: "inteiro", value: "10", currency: "BRL", want: 1000},
+		{name: "uma casa", value: "10.5", currency: "BRL", want: 1050},
+		{name: "negativo", value: "-1.25", currency: "BRL", want: -125},
+		{name: "espacos", value: " 1.00 ", currency: "BRL", want: 100},
+		{name: "zeros a direita", value: "1.230", currency: "BRL", want: 123},
+		{name: "moeda sem casas", value: "100", currency: "JPY", want: 100},
+		{name: "moeda com tres casas", value: "1.234", currency: "KWD", want: 1234},
+		{name: "casas demais", value: "1.234", currency: "BRL", wantErr: ErrInvalidAmount},
+		{name: "casas em moeda sem casas", value: "100.5", currency: "JPY", wantErr: ErrInvalidAmount},
+		{name: "texto", value: "abc", currency: "BRL", wantErr: ErrInvalidAmount},
+		{name: "vazio", value: "", currency: "BRL", wantErr: ErrInvalidAmount},
+		{name: "so sinal", value: "-", currency: "BRL", wantErr: ErrInvalidAmount},
+		{name: "sinal duplo", value: "--1", currency: "BRL", wantErr: ErrInvalidAmount},
+		{name: "sinal positivo", value: "+1", currency: "BRL", wantErr: ErrInvalidAmount},
+		{name: "ponto sem fracao", value: "1.", currency: "BRL", wantErr: ErrInvalidAmount},
+		{name: "ponto sem inteiro", value: ".5", currency: "BRL", wantErr: ErrInvalidAmount},
+		{name: "dois pontos", value: "1.2.3", currency: "BRL", wantErr: ErrInvalidAmount},
+		{name: "notacao cientifica", value: "1e3", currency: "BRL", wantErr: ErrInvalidAmount},
+		{name: "virgula", value: "1,50", currency: "BRL", wantErr: ErrInvalidAmount},
+		{name: "overflow", value: "99999999999999999999", currency: "BRL", wantErr: ErrAmountOverflow},
+		{name: "moeda desconhecida", value: "1.00", currency: "XYZ", wantErr: ErrUnknownCurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMoney(tt.value, tt.currency)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ParseMoney(%q, %s) erro = %v, esperado %v", tt.value, tt.currency, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseMoney(%q, %s) erro inesperado: %v", tt.value, tt.currency, err)
+			}
+			if got.MinorUnits() != tt.want || got.Currency() != tt.currency {
+				t.Fatalf("ParseMoney(%q, %s) = %d %s, esperado %d %s", tt.value, tt.currency, got.MinorUnits(), got.Currency(), tt.want, tt.currency)
+			}
+		})
+	}
+}
+
+func TestMoneyPercent(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  int64
+		bps     int64
+		want    int64
+		wantErr error
+	}{
+		{name: "exato", amount: 10000, bps: 1000, want: 1000},
+		{name: "empate arredonda para o par abaixo", amount: 25, bps: 1000, want: 2},
+		{name: "empate arredonda para o par acima", amount: 35, bps: 1000, want: 4},
+		{name: "meio centavo para zero", amount: 1, bps: 5000, want: 0},
+		{name: "um e meio para dois", amount: 3, bps: 5000, want: 2},
+		{name: "acima do meio", amount: 26, bps: 1000, want: 3},
+		{name: "abaixo do meio", amount: 24, bps: 1000, want: 2},
+		{name: "negativo empate para o par", amount: -25, bps: 1000, want: -2},
+		{name: "negativo empate para o par acima", amount: -35, bps: 1000, want: -4},
+		{name: "negativo acima do meio", amount: -26, bps: 1000, want: -3},
+		{name: "zero pontos-base", amount: 459990, bps: 0, want: 0},
+		{name: "overflow", amount: math.MaxInt64, bps: 20000, wantErr: ErrAmountOverflow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Money{amount: tt.amount, currency: "BRL"}.Percent(tt.bps)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Percent(%d) de %d erro = %v, esperado %v", tt.bps, tt.amount, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Percent(%d) de %d erro inesperado: %v", tt.bps, tt.amount, err)
+			}
+			if got.MinorUnits() != tt.want {
+				t.Fatalf("Percent(%d) de %d = %d, esperado %d", tt.bps, tt.amount, got.MinorUnits(), tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	brl := func(amount int64) Money { return Money{amount: amount, currency: "BRL"} }
+	usd := func(amount int64) Money { return Money{amount: amount, currency: "USD"} }
+
+	tests := []struct {
+		name    string
+		op      func() (Money, error)
+		want    int64
+		wantErr error
+	}{
+		{name: "soma", op: func() (Money, error) { return brl(150).Add(brl(250)) }, want: 400},
+		{name: "soma com negativo", op: func() (Money, error) { return brl(150).Add(brl(-250)) }, want: -100},
+		{name: "soma com moedas diferentes", op: func() (Money, error) { return brl(150).Add(usd(250)) }, wantErr: ErrCurrencyMismatch},
+		{name: "overflow na soma", op: func() (Money, error) { return brl(math.MaxInt64).Add(brl(1)) }, wantErr: ErrAmountOverflow},
+		{name: "underflow na soma", op: func() (Money, error) { return brl(math.MinInt64).Add(brl(-1)) }, wantErr: ErrAmountOverflow},
+		{name: "subtracao", op: func() (Money, error) { return brl(1000).Sub(brl(1)) }, want: 999},
+		{name: "subtracao com moedas diferentes", op: func() (Money, error) { return brl(1000).Sub(usd(1)) }, wantErr: ErrCurrencyMismatch},
+		{name: "subtracao do minimo", op: func() (Money, error) { return brl(0).Sub(brl(math.MinInt64)) }, wantErr: ErrAmountOverflow},
+		{name: "multiplicacao", op: func() (Money, error) { return brl(459990).Mul(2) }, want: 919980},
+		{name: "overflow na multiplicacao", op: func() (Money, error) { return brl(math.MaxInt64 / 2).Mul(3) }, wantErr: ErrAmountOverflow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.op()
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("erro = %v, esperado %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if got.MinorUnits() != tt.want {
+				t.Fatalf("resultado = %d, esperado %d", got.MinorUnits(), tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{money: Money{amount: 459990, currency: "BRL"}, want: "4599.90"},
+		{money: Money{amount: 5, currency: "BRL"}, want: "0.05"},
+		{money: Money{amount: -125, currency: "BRL"}, want: "-1.25"},
+		{money: Money{amount: 100, currency: "JPY"}, want: "100"},
+		{money: Money{amount: 1234, currency: "KWD"}, want: "1.234"},
+		{money: Money{amount: math.MinInt64, currency: "BRL"}, want: "-92233720368547758.08"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.money.String(); got != tt.want {
+			t.Errorf("String() de %d %s = %q, esperado %q", tt.money.amount, tt.money.currency, got, tt.want)
+		}
+	}
+}
+
+func TestCalculateTotals(t *testing.T) {
+	item := func(quantity int, price, currency string) OrderItem {
+		orderItem, err := NewOrderItem("SKU", "Item", quantity, MustParseMoney(price, currency))
+		if err != nil {
+			t.Fatalf("NewOrderItem: %v", err)
+		}
+		return orderItem
+	}
+
+	tests := []struct {
+		name        string
+		items       []OrderItem
+		discountBps int64
+		taxBps      int64
+		want        [4]string // subtotal, desconto, imposto, total
+		wantErr     error
+	}{
+		{
+			name:  "sem desconto nem imposto",
+			items: []OrderItem{item(2, "4599.90", "BRL"), item(1, "0.10", "BRL")},
+			want:  [4]string{"9199.90", "0.00", "0.00", "9199.90"},
+		},
+		{
+			// O imposto incide sobre o subtotal com desconto: 18% de 8279.82, e
+			// não de 9199.80.
+			name:        "desconto antes do imposto",
+			items:       []OrderItem{item(2, "4599.90", "BRL")},
+			discountBps: 1000,
+			taxBps:      1800,
+			want:        [4]string{"9199.80", "919.98", "1490.37", "9770.19"},
+		},
+		{
+			name:        "empates arredondados para o par",
+			items:       []OrderItem{item(1, "0.25", "BRL")},
+			discountBps: 1000,
+			taxBps:      5000,
+			// desconto 0.025 -> 0.02; imposto de 0.23 = 0.115 -> 0.12
+			want: [4]string{"0.25", "0.02", "0.12", "0.35"},
+		},
+		{
+			name:    "moedas diferentes",
+			items:   []OrderItem{item(1, "10.00", "BRL"), item(1, "10.00", "USD")},
+			wantErr: ErrCurrencyMismatch,
+		},
+		{
+			name:    "sem itens",
+			wantErr: ErrInvalidAmount,
+		},
+		{
+			name: "overflow no subtotal",
+			items: []OrderItem{
+				{Total: Money{amount: math.MaxInt64, currency: "BRL"}},
+				{Total: Money{amount: 1, currency: "BRL"}},
+			},
+			wantErr: ErrAmountOverflow,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			totals, err := CalculateTotals(tt.items, tt.discountBps, tt.taxBps)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("erro = %v, esperado %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			got := [4]string{totals.Subtotal.String(), totals.Discount.String(), totals.Tax.String(), totals.Total.String()}
+			if got != tt.want {
+				t.Fatalf("totais = %v, esperado %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package domain
+
+import "time"
+
+type PaymentStatus string
+
+const (
+	PaymentAuthorized PaymentStatus = "AUTORIZADO"
+	PaymentCaptured   PaymentStatus = "CAPTURADO"
+	PaymentDeclined   PaymentStatus = "RECUSADO"
+	PaymentRefunded   PaymentStatus = "ESTORNADO"
+	PaymentVoided     PaymentStatus = "CANCELADO"
+)
+
+// Payment é o estado do pagamento do pedido no gateway, com os identificadores
+// de cada transação devolvidos por ele.
+type Payment struct {
+	Status          PaymentStatus `json:"status" bson:"status"`
+	Amount          Money         `json:"amount" bson:"amount"`
+	AuthorizationID string        `json:"authorization_id,omitempty" bson:"authorization_id,omitempty"`
+	CaptureID       string        `json:"capture_id,omitempty" bson:"capture_id,omitempty"`
+	RefundID        string        `json:"refund_id,omitempty" bson:"refund_id,omitempty"`
+	VoidID          string        `json:"void_id,omitempty" bson:"void_id,omitempty"`
+	DeclineReason   string        `json:"decline_reason,omitempty" bson:"decline_reason,omitempty"`
+	UpdatedAt       time.Time     `json:"updated_at" bson:"updated_at"`
+}
@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Status string
+
+const (
+	StatusCriado      Status = "CRIADO"
+	StatusProcessando Status = "PROCESSANDO"
+	StatusProcessado  Status = "PROCESSADO"
+	StatusFalhou      Status = "FALHOU"
+	StatusCancelado   Status = "CANCELADO"
+	StatusReembolsado Status = "REEMBOLSADO"
+	// StatusRejeitado indica que o pedido não pôde ser atendido (ex.: falta de
+	// estoque). O motivo fica no histórico.
+	StatusRejeitado Status = "REJEITADO"
+)
+
+var ErrInvalidTransition = errors.New("transição de status inválida")
+
+// transitions é a máquina de estados do pedido, compartilhada pela API e pelo worker.
+// Status sem entrada no mapa são finais.
+var transitions = map[Status][]Status{
+	StatusCriado:      {StatusProcessando, StatusCancelado},
+	StatusProcessando: {StatusProcessado, StatusFalhou, StatusCancelado, StatusRejeitado},
+	StatusProcessado:  {StatusReembolsado},
+	StatusFalhou:      {StatusProcessando, StatusCancelado},
+}
+
+var statuses = []Status{
+	StatusCriado,
+	StatusProcessando,
+	StatusProcessado,
+	StatusFalhou,
+	StatusCancelado,
+	StatusReembolsado,
+	StatusRejeitado,
+}
+
+func Statuses() []Status {
+	return append([]Status(nil), statuses...)
+}
+
+func (s Status) IsValid() bool {
+	for _, status := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Status) IsFinal() bool {
+	return len(transitions[s]) == 0
+}
+
+func (s Status) CanTransitionTo(to Status) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+type TransitionError struct {
+	OrderID string
+	From    Status
+	To      Status
+	Current Status
+}
+
+func (e *TransitionError) Error() string {
+	if e.Current != "" && e.Current != e.From {
+		return fmt.Sprintf("%s: pedido %s está em %s, esperado %s para ir para %s",
+			ErrInvalidTransition, e.OrderID, e.Current, e.From, e.To)
+	}
+	return fmt.Sprintf("%s: %s -> %s", ErrInvalidTransition, e.From, e.To)
+}
+
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrInvalidTransition
+}
+
+func ValidateTransition(from, to Status) error {
+	if !from.CanTransitionTo(to) {
+		return &TransitionError{From: from, To: to, Current: from}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package domain
+
+import "time"
+
+// StepOutcome é o resultado da última execução de uma etapa do processamento
+// do pedido no worker.
+type StepOutcome string
+
+const (
+	StepSucceeded       StepOutcome = "CONCLUIDA"
+	StepFailedRetryable StepOutcome = "FALHA_TEMPORARIA"
+	StepFailedPermanent StepOutcome = "FALHA_PERMANENTE"
+)
+
+// StepRecord fica gravado no pedido, na ordem das etapas, para que uma nova
+// entrega da mensagem retome a partir da primeira etapa não concluída.
+// Compensation registra a desfeita de uma etapa concluída, com os mesmos
+// valores de StepOutcome.
+type StepRecord struct {
+	Name      string      `json:"name" bson:"name"`
+	Outcome   StepOutcome `json:"outcome" bson:"outcome"`
+	Attempts  int         `json:"attempts" bson:"attempts"`
+	Error     string      `json:"error,omitempty" bson:"error,omitempty"`
+	UpdatedAt time.Time   `json:"updated_at" bson:"updated_at"`
+
+	Compensation      StepOutcome `json:"compensation,omitempty" bson:"compensation,omitempty"`
+	CompensationError string      `json:"compensation_error,omitempty" bson:"compensation_error,omitempty"`
+	CompensatedAt     *time.Time  `json:"compensated_at,omitempty" bson:"compensated_at,omitempty"`
+}
+
+// NeedsCompensation inclui as etapas que falharam: elas podem ter deixado
+// efeitos parciais, como um pagamento autorizado cuja captura falhou.
+func (r StepRecord) NeedsCompensation() bool {
+	return r.Outcome != "" && r.Compensation != StepSucceeded
+}
+
+type SagaStatus string
+
+const (
+	SagaRunning      SagaStatus = "EM_ANDAMENTO"
+	SagaCompleted    SagaStatus = "CONCLUIDA"
+	SagaCompensating SagaStatus = "COMPENSANDO"
+	SagaCompensated  SagaStatus = "COMPENSADA"
+)
+
+// SagaState é o progresso da saga de processamento do pedido. Um pedido em
+// COMPENSANDO retoma as compensações na próxima entrega da mensagem.
+type SagaState struct {
+	Status     SagaStatus `json:"status" bson:"status"`
+	FailedStep string     `json:"failed_step,omitempty" bson:"failed_step,omitempty"`
+	Error      string     `json:"error,omitempty" bson:"error,omitempty"`
+	UpdatedAt  time.Time  `json:"updated_at" bson:"updated_at"`
+}
@@ -1,3 +1,8 @@
 module github.com/dev-bruno-arruda/api-pedidos
 
 go 1.24
+
+require (
+	github.com/rabbitmq/amqp091-go v1.10.0
+	go.mongodb.org/mongo-driver v1.17.1
+)
@@ -0,0 +1,271 @@
+// Package rabbitmq mantém a conexão dos serviços com o RabbitMQ, com
+// reconexão automática.
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+type ConnectionState string
+
+const (
+	StateConnecting   ConnectionState = "CONECTANDO"
+	StateConnected    ConnectionState = "CONECTADO"
+	StateReconnecting ConnectionState = "RECONECTANDO"
+	StateClosed       ConnectionState = "FECHADO"
+)
+
+var (
+	ErrNotConnected     = errors.New("sem conexão com o RabbitMQ")
+	ErrConnectionClosed = errors.New("conexão com o RabbitMQ encerrada")
+)
+
+// SetupFunc prepara um canal recém-aberto (declaração de topologia, QoS, modo de
+// confirmação...). É chamada na conexão inicial e a cada reconexão.
+type SetupFunc func(channel *amqp.Channel) error
+
+type ConnectionConfig struct {
+	URI           string
+	MaxRetries    int
+	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
+}
+
+// ConnectionManager mantém uma conexão e um canal com o RabbitMQ. Quando a
+// conexão ou o canal caem, redisca com backoff exponencial e jitter e executa
+// o SetupFunc novamente no novo canal.
+type ConnectionManager struct {
+	config ConnectionConfig
+	setup  SetupFunc
+
+	mu      sync.RWMutex
+	conn    *amqp.Connection
+	channel *amqp.Channel
+	state   ConnectionState
+	ready   chan struct{}
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func NewConnectionManager(config ConnectionConfig, setup SetupFunc) (*ConnectionManager, error) {
+	m := &ConnectionManager{
+		config: config,
+		setup:  setup,
+		state:  StateConnecting,
+		ready:  make(chan struct{}),
+		closed: make(chan struct{}),
+	}
+
+	var err error
+	for attempt := 1; attempt <= config.MaxRetries; attempt++ {
+		err = m.connect()
+		if err == nil {
+			break
+		}
+		log.Printf("[WARN] Tentativa %d de %d: Falha ao conectar ao RabbitMQ: %v", attempt, config.MaxRetries, err)
+		if attempt < config.MaxRetries {
+			time.Sleep(m.backoff(attempt))
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("falha ao conectar ao RabbitMQ após %d tentativas: %w", config.MaxRetries, err)
+	}
+
+	go m.watch()
+
+	return m, nil
+}
+
+func (m *ConnectionManager) connect() error {
+	conn, err := amqp.Dial(m.config.URI)
+	if err != nil {
+		return err
+	}
+
+	channel, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("falha ao abrir canal: %w", err)
+	}
+
+	if m.setup != nil {
+		if err := m.setup(channel); err != nil {
+			channel.Close()
+			conn.Close()
+			return err
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Close pode ter rodado durante a discagem; a nova conexão não pode
+	// sobreviver ao encerramento.
+	if m.state == StateClosed {
+		channel.Close()
+		conn.Close()
+		return ErrConnectionClosed
+	}
+
+	m.conn = conn
+	m.channel = channel
+	m.state = StateConnected
+	close(m.ready)
+
+	return nil
+}
+
+func (m *ConnectionManager) watch() {
+	for {
+		m.mu.RLock()
+		connClose := m.conn.NotifyClose(make(chan *amqp.Error, 1))
+		channelClose := m.channel.NotifyClose(make(chan *amqp.Error, 1))
+		m.mu.RUnlock()
+
+		select {
+		case <-m.closed:
+			return
+		case err := <-connClose:
+			log.Printf("[ERROR] Conexão com RabbitMQ fechada: %v", err)
+		case err := <-channelClose:
+			log.Printf("[ERROR] Canal do RabbitMQ fechado: %v", err)
+		}
+
+		m.mu.Lock()
+		if m.state == StateClosed {
+			m.mu.Unlock()
+			return
+		}
+		m.state = StateReconnecting
+		m.ready = make(chan struct{})
+		conn := m.conn
+		m.mu.Unlock()
+
+		// Se apenas o canal caiu, a conexão antiga também é descartada.
+		if !conn.IsClosed() {
+			conn.Close()
+		}
+
+		if !m.reconnect() {
+			return
+		}
+	}
+}
+
+func (m *ConnectionManager) reconnect() bool {
+	for attempt := 1; ; attempt++ {
+		delay := m.backoff(attempt)
+		log.Printf("[INFO] Reconectando ao RabbitMQ em %s (tentativa %d)", delay, attempt)
+
+		select {
+		case <-m.closed:
+			return false
+		case <-time.After(delay):
+		}
+
+		err := m.connect()
+		if errors.Is(err, ErrConnectionClosed) {
+			return false
+		}
+		if err != nil {
+			log.Printf("[WARN] Falha ao reconectar ao RabbitMQ: %v", err)
+			continue
+		}
+
+		log.Println("[INFO] Reconectado ao RabbitMQ com sucesso")
+		return true
+	}
+}
+
+func (m *ConnectionManager) backoff(attempt int) time.Duration {
+	delay := m.config.RetryDelay
+	for i := 1; i < attempt && delay < m.config.MaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > m.config.MaxRetryDelay {
+		delay = m.config.MaxRetryDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	// Jitter de até 50% evita que várias instâncias redisquem ao mesmo tempo.
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// Channel retorna o canal atual ou ErrNotConnected durante uma reconexão.
+func (m *ConnectionManager) Channel() (*amqp.Channel, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	switch m.state {
+	case StateConnected:
+		return m.channel, nil
+	case StateClosed:
+		return nil, ErrConnectionClosed
+	default:
+		return nil, ErrNotConnected
+	}
+}
+
+// WaitChannel bloqueia até haver um canal disponível, o contexto ser cancelado
+// ou o gerenciador ser encerrado.
+func (m *ConnectionManager) WaitChannel(ctx context.Context) (*amqp.Channel, error) {
+	for {
+		m.mu.RLock()
+		state, channel, ready := m.state, m.channel, m.ready
+		m.mu.RUnlock()
+
+		switch state {
+		case StateConnected:
+			return channel, nil
+		case StateClosed:
+			return nil, ErrConnectionClosed
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-m.closed:
+			return nil, ErrConnectionClosed
+		case <-ready:
+		}
+	}
+}
+
+func (m *ConnectionManager) State() ConnectionState {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state
+}
+
+func (m *ConnectionManager) Close() error {
+	var err error
+	m.closeOnce.Do(func() {
+		close(m.closed)
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		m.state = StateClosed
+		if m.channel != nil && !m.channel.IsClosed() {
+			if closeErr := m.channel.Close(); closeErr != nil {
+				err = closeErr
+			}
+		}
+		if m.conn != nil && !m.conn.IsClosed() {
+			if closeErr := m.conn.Close(); closeErr != nil && err == nil {
+				err = closeErr
+			}
+		}
+	})
+	return err
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dev-bruno-arruda/api-pedidos/worker_service/pkg/broker"
+	"github.com/dev-bruno-arruda/api-pedidos/worker_service/pkg/config"
+	"github.com/dev-bruno-arruda/api-pedidos/worker_service/pkg/models"
+)
+
+const usage = `Uso: dlq <comando> [opções]
+
+Comandos:
+  list [-json]            lista as mensagens da DLQ com motivo da falha e tentativas
+  replay -all | <id>...   republica as mensagens em orders_queue e as remove da DLQ
+  purge -all | <id>...    remove as mensagens da DLQ
+
+Os ids podem ser o message_id ou o order_id da mensagem.
+`
+
+func main() {
+	log.SetFlags(0)
+
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	command, args := os.Args[1], os.Args[2:]
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	asJSON := flags.Bool("json", false, "saída em JSON")
+	all := flags.Bool("all", false, "aplica o comando a todas as mensagens")
+	flags.Parse(args)
+
+	var selector broker.DeadLetterSelector
+	switch command {
+	case "list":
+	case "replay", "purge":
+		switch {
+		case *all && flags.NArg() > 0:
+			log.Fatalf("use -all ou uma lista de ids, não os dois")
+		case *all:
+			selector = broker.SelectAllDeadLetters
+		case flags.NArg() > 0:
+			selector = broker.SelectDeadLetters(flags.Args()...)
+		default:
+			log.Fatalf("informe -all ou ao menos um id")
+		}
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cfg := config.Load()
+
+	consumer, err := broker.NewRabbitMQConsumer(broker.ConsumerConfig{
+		URI:           cfg.RabbitMQ.URI,
+		QueueName:     cfg.RabbitMQ.QueueName,
+		MaxRetries:    cfg.RabbitMQ.MaxRetries,
+		RetryDelay:    cfg.RabbitMQ.RetryDelay,
+		MaxRetryDelay: cfg.RabbitMQ.MaxRetryDelay,
+		PrefetchCount: cfg.RabbitMQ.PrefetchCount,
+		MaxAttempts:   cfg.RabbitMQ.MaxAttempts,
+		RetryDelays:   cfg.RabbitMQ.RetryDelays,
+	})
+	if err != nil {
+		log.Fatalf("Erro ao conectar ao RabbitMQ: %v", err)
+	}
+	defer consumer.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	switch command {
+	case "list":
+		messages, err := consumer.ListDeadLetters(ctx)
+		if err != nil {
+			log.Fatalf("Erro ao listar DLQ: %v", err)
+		}
+		if *asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(messages); err != nil {
+				log.Fatalf("Erro ao gerar JSON: %v", err)
+			}
+			return
+		}
+		printDeadLetters(messages)
+
+	case "replay":
+		count, err := consumer.ReplayDeadLetters(ctx, selector)
+		if err != nil {
+			log.Fatalf("Erro ao republicar mensagens (%d republicadas antes do erro): %v", count, err)
+		}
+		fmt.Printf("%d mensagem(ns) republicada(s) em %s\n", count, cfg.RabbitMQ.QueueName)
+
+	case "purge":
+		count, err := consumer.PurgeDeadLetters(ctx, selector)
+		if err != nil {
+			log.Fatalf("Erro ao remover mensagens (%d removidas antes do erro): %v", count, err)
+		}
+		fmt.Printf("%d mensagem(ns) removida(s) da DLQ\n", count)
+	}
+}
+
+func printDeadLetters(messages []models.DeadLetterMessage) {
+	if len(messages) == 0 {
+		fmt.Println("DLQ vazia")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MESSAGE_ID\tORDER_ID\tTENTATIVAS\tFALHOU_EM\tMOTIVO")
+	for _, message := range messages {
+		failedAt := "-"
+		if message.FailedAt != nil {
+			failedAt = message.FailedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n",
+			valueOrDash(message.MessageID), valueOrDash(message.OrderID), message.Attempts, failedAt, valueOrDash(message.Reason))
+	}
+	w.Flush()
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
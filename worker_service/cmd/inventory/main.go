@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/dev-bruno-arruda/api-pedidos/worker_service/pkg/config"
+	"github.com/dev-bruno-arruda/api-pedidos/worker_service/pkg/models"
+	"github.com/dev-bruno-arruda/api-pedidos/worker_service/pkg/repository"
+)
+
+const usage = `Uso: inventory <comando> [opções]
+
+Comandos:
+  list [-json]                 lista os produtos do catálogo com o estoque disponível
+  set <sku> <estoque> [nome]   cadastra o produto ou redefine seu estoque disponível
+`
+
+func main() {
+	log.SetFlags(0)
+
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	command, args := os.Args[1], os.Args[2:]
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	asJSON := flags.Bool("json", false, "saída em JSON")
+	flags.Parse(args)
+
+	var product models.Product
+	switch command {
+	case "list":
+	case "set":
+		if flags.NArg() < 2 {
+			log.Fatalf("informe o SKU e o estoque")
+		}
+		stock, err := strconv.Atoi(flags.Arg(1))
+		if err != nil || stock < 0 {
+			log.Fatalf("estoque inválido: %s", flags.Arg(1))
+		}
+		product = models.Product{
+			SKU:   flags.Arg(0),
+			Name:  strings.Join(flags.Args()[2:], " "),
+			Stock: stock,
+		}
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cfg := config.Load()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	mongoClient, err := repository.ConnectMongoDB(ctx, repository.MongoDBConfig{
+		URI:             cfg.MongoDB.URI,
+		Database:        cfg.MongoDB.Database,
+		MaxPoolSize:     cfg.MongoDB.MaxPoolSize,
+		MinPoolSize:     cfg.MongoDB.MinPoolSize,
+		MaxConnIdleTime: cfg.MongoDB.MaxConnIdleTime,
+		ConnectTimeout:  cfg.MongoDB.ConnectTimeout,
+	})
+	if err != nil {
+		log.Fatalf("Erro ao conectar ao MongoDB: %v", err)
+	}
+	defer mongoClient.Disconnect(context.Background())
+
+	inventory := repository.NewInventoryRepository(mongoClient, cfg.MongoDB.Database, cfg.MongoDB.ProductsCollection, cfg.MongoDB.ReservationsCollection)
+
+	switch command {
+	case "list":
+		products, err := inventory.FindProducts(ctx)
+		if err != nil {
+			log.Fatalf("Erro ao listar produtos: %v", err)
+		}
+		if *asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(products); err != nil {
+				log.Fatalf("Erro ao gerar JSON: %v", err)
+			}
+			return
+		}
+		printProducts(products)
+
+	case "set":
+		if err := inventory.SetProduct(ctx, product); err != nil {
+			log.Fatalf("Erro ao salvar produto: %v", err)
+		}
+		fmt.Printf("Produto %s salvo com estoque %d\n", product.SKU, product.Stock)
+	}
+}
+
+func printProducts(products []models.Product) {
+	if len(products) == 0 {
+		fmt.Println("Catálogo vazio")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SKU\tESTOQUE\tNOME")
+	for _, product := range products {
+		name := product.Name
+		if name == "" {
+			name = "-"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\n", product.SKU, product.Stock, name)
+	}
+	w.Flush()
+}
@@ -2,14 +2,19 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/dev-bruno-arruda/api-pedidos/rabbitmq"
 	"github.com/dev-bruno-arruda/api-pedidos/worker_service/pkg/broker"
 	"github.com/dev-bruno-arruda/api-pedidos/worker_service/pkg/config"
+	"github.com/dev-bruno-arruda/api-pedidos/worker_service/pkg/payment"
+	"github.com/dev-bruno-arruda/api-pedidos/worker_service/pkg/ports"
 	"github.com/dev-bruno-arruda/api-pedidos/worker_service/pkg/repository"
 	"github.com/dev-bruno-arruda/api-pedidos/worker_service/pkg/service"
 )
@@ -39,8 +44,11 @@ func main() {
 		QueueName:     cfg.RabbitMQ.QueueName,
 		MaxRetries:    cfg.RabbitMQ.MaxRetries,
 		RetryDelay:    cfg.RabbitMQ.RetryDelay,
+		MaxRetryDelay: cfg.RabbitMQ.MaxRetryDelay,
 		PrefetchCount: cfg.RabbitMQ.PrefetchCount,
 		Workers:       cfg.Worker.Workers,
+		MaxAttempts:   cfg.RabbitMQ.MaxAttempts,
+		RetryDelays:   cfg.RabbitMQ.RetryDelays,
 	})
 	if err != nil {
 		log.Fatalf("Erro ao conectar ao RabbitMQ: %v", err)
@@ -48,7 +56,56 @@ func main() {
 	log.Println("Conectado ao RabbitMQ")
 
 	orderRepo := repository.NewOrderRepository(mongoClient, cfg.MongoDB.Database, cfg.MongoDB.Collection)
-	orderProcessor := service.NewOrderProcessor(orderRepo, cfg.Worker.ProcessingDelay)
+	processedRepo := repository.NewProcessedMessageRepository(mongoClient, cfg.MongoDB.Database, cfg.MongoDB.ProcessedCollection)
+	if err := processedRepo.EnsureIndexes(context.Background(), cfg.MongoDB.IdempotencyTTL); err != nil {
+		log.Fatalf("Erro ao criar índices de mensagens processadas: %v", err)
+	}
+	inventoryRepo := repository.NewInventoryRepository(mongoClient, cfg.MongoDB.Database, cfg.MongoDB.ProductsCollection, cfg.MongoDB.ReservationsCollection)
+	paymentMode, err := payment.ParseFakeMode(cfg.Payment.FakeMode)
+	if err != nil {
+		log.Fatalf("Erro na configuração de pagamento: %v", err)
+	}
+	paymentGateway := payment.NewFakeGateway(paymentMode, cfg.Payment.FakeLatency)
+	log.Printf("Gateway de pagamento fake no modo %s", paymentMode)
+
+	orderProcessor := service.NewOrderProcessor(orderRepo, processedRepo, []ports.OrderStep{
+		service.NewValidateStep(),
+		service.NewReserveStockStep(inventoryRepo),
+		service.NewChargePaymentStep(paymentGateway, orderRepo, cfg.Payment.Timeout),
+		service.NewNotifyStep(repository.NewCustomerRepository(mongoClient, cfg.MongoDB.Database, cfg.MongoDB.CustomersCollection)),
+	}, cfg.Worker.InstanceID, cfg.Worker.SagaLeaseTTL)
+
+	var sagaSweeper *service.SagaSweeper
+	if cfg.Worker.SagaSweepInterval > 0 {
+		sagaSweeper = service.NewSagaSweeper(orderRepo, orderProcessor, service.SagaSweeperConfig{
+			Interval:   cfg.Worker.SagaSweepInterval,
+			StuckAfter: cfg.Worker.SagaStuckAfter,
+		})
+		sagaSweeper.Start()
+		log.Printf("Varredura de sagas paradas a cada %s (paradas há mais de %s)", cfg.Worker.SagaSweepInterval, cfg.Worker.SagaStuckAfter)
+	}
+
+	healthMux := http.NewServeMux()
+	healthMux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		if state := consumer.State(); state != rabbitmq.StateConnected {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintf(w, "unhealthy: rabbitmq %s", state)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "healthy")
+	})
+	healthServer := &http.Server{
+		Addr:    ":" + cfg.Worker.HealthPort,
+		Handler: healthMux,
+	}
+
+	go func() {
+		log.Printf("Health check rodando na porta %s", cfg.Worker.HealthPort)
+		if err := healthServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Printf("Erro ao iniciar servidor de health check: %v", err)
+		}
+	}()
 
 	ctx, cancel := context.WithCancel(context.Background())
 
@@ -74,12 +131,20 @@ func main() {
 	cancel()
 	log.Println("Consumer parado")
 
+	if sagaSweeper != nil {
+		sagaSweeper.Shutdown()
+	}
+
 	log.Println("Aguardando processamento de mensagens pendentes")
 	time.Sleep(cfg.Worker.ShutdownWait)
 
 	cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), cfg.Shutdown.CleanupTimeout)
 	defer cleanupCancel()
 
+	if err := healthServer.Shutdown(cleanupCtx); err != nil {
+		log.Printf("Erro ao encerrar servidor de health check: %v", err)
+	}
+
 	if err := consumer.Close(); err != nil {
 		log.Printf("Erro ao fechar conexão com RabbitMQ: %v", err)
 	} else {
@@ -3,6 +3,7 @@ module github.com/dev-bruno-arruda/api-pedidos/worker_service
 go 1.24
 
 require (
+	github.com/dev-bruno-arruda/api-pedidos v0.0.0
 	github.com/rabbitmq/amqp091-go v1.10.0
 	go.mongodb.org/mongo-driver v1.17.1
 )
@@ -19,3 +20,5 @@ require (
 	golang.org/x/sync v0.8.0 // indirect
 	golang.org/x/text v0.17.0 // indirect
 )
+
+replace github.com/dev-bruno-arruda/api-pedidos => ../
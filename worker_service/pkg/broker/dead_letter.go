@@ -0,0 +1,203 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/dev-bruno-arruda/api-pedidos/worker_service/pkg/models"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// DeadLetterSelector indica se uma mensagem da DLQ deve ser afetada pela operação.
+type DeadLetterSelector func(message models.DeadLetterMessage) bool
+
+func SelectAllDeadLetters(models.DeadLetterMessage) bool {
+	return true
+}
+
+// SelectDeadLetters seleciona mensagens pelo message_id ou pelo order_id.
+func SelectDeadLetters(ids ...string) DeadLetterSelector {
+	selected := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		selected[id] = true
+	}
+	return func(message models.DeadLetterMessage) bool {
+		return (message.MessageID != "" && selected[message.MessageID]) ||
+			(message.OrderID != "" && selected[message.OrderID])
+	}
+}
+
+func (c *RabbitMQConsumer) ListDeadLetters(ctx context.Context) ([]models.DeadLetterMessage, error) {
+	var messages []models.DeadLetterMessage
+	err := c.scanDeadLetters(ctx, func(channel *amqp.Channel, delivery amqp.Delivery, message models.DeadLetterMessage) (bool, error) {
+		messages = append(messages, message)
+		return false, nil
+	})
+	return messages, err
+}
+
+// ReplayDeadLetters republica as mensagens selecionadas em orders_queue com o
+// contador de tentativas zerado e as remove da DLQ.
+func (c *RabbitMQConsumer) ReplayDeadLetters(ctx context.Context, selector DeadLetterSelector) (int, error) {
+	replayed := 0
+	confirmEnabled := false
+
+	err := c.scanDeadLetters(ctx, func(channel *amqp.Channel, delivery amqp.Delivery, message models.DeadLetterMessage) (bool, error) {
+		if !selector(message) {
+			return false, nil
+		}
+
+		if !confirmEnabled {
+			if err := channel.Confirm(false); err != nil {
+				return false, fmt.Errorf("falha ao ativar modo de confirmação: %w", err)
+			}
+			confirmEnabled = true
+		}
+
+		headers := amqp.Table{}
+		for key, value := range delivery.Headers {
+			switch key {
+			case headerRetryCount, headerFailureReason, headerFailedAt, "x-death",
+				"x-first-death-exchange", "x-first-death-queue", "x-first-death-reason",
+				"x-last-death-exchange", "x-last-death-queue", "x-last-death-reason":
+				continue
+			}
+			headers[key] = value
+		}
+		headers["x-replayed-at"] = time.Now().UTC().Format(time.RFC3339)
+
+		confirmation, err := channel.PublishWithDeferredConfirmWithContext(ctx, "", c.queueName, false, false, amqp.Publishing{
+			Headers:      headers,
+			ContentType:  delivery.ContentType,
+			MessageId:    delivery.MessageId,
+			Body:         delivery.Body,
+			DeliveryMode: amqp.Persistent,
+		})
+		if err != nil {
+			return false, fmt.Errorf("erro ao republicar mensagem %s: %w", message.MessageID, err)
+		}
+
+		acked, err := confirmation.WaitContext(ctx)
+		if err != nil {
+			return false, fmt.Errorf("erro ao aguardar confirmação da mensagem %s: %w", message.MessageID, err)
+		}
+		if !acked {
+			return false, fmt.Errorf("broker rejeitou a republicação da mensagem %s", message.MessageID)
+		}
+
+		replayed++
+		return true, nil
+	})
+	return replayed, err
+}
+
+func (c *RabbitMQConsumer) PurgeDeadLetters(ctx context.Context, selector DeadLetterSelector) (int, error) {
+	purged := 0
+	err := c.scanDeadLetters(ctx, func(channel *amqp.Channel, delivery amqp.Delivery, message models.DeadLetterMessage) (bool, error) {
+		if !selector(message) {
+			return false, nil
+		}
+		purged++
+		return true, nil
+	})
+	return purged, err
+}
+
+// scanDeadLetters lê todas as mensagens da DLQ com basic.get sem ack. Para cada
+// uma, visit decide se ela deve ser removida (ack); as demais voltam para a DLQ
+// ao final da varredura. Como as mensagens ficam pendentes até o fim, cada uma
+// é visitada uma única vez.
+func (c *RabbitMQConsumer) scanDeadLetters(ctx context.Context, visit func(*amqp.Channel, amqp.Delivery, models.DeadLetterMessage) (bool, error)) error {
+	channel, err := c.connection.WaitChannel(ctx)
+	if err != nil {
+		return err
+	}
+
+	var pending []amqp.Delivery
+	defer func() {
+		for _, delivery := range pending {
+			delivery.Nack(false, true)
+		}
+	}()
+
+	dlq := DeadLetterQueueName(c.queueName)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		delivery, ok, err := channel.Get(dlq, false)
+		if err != nil {
+			return fmt.Errorf("erro ao ler DLQ: %w", err)
+		}
+		if !ok {
+			return nil
+		}
+
+		remove, err := visit(channel, delivery, toDeadLetterMessage(delivery))
+		if err != nil {
+			delivery.Nack(false, true)
+			return err
+		}
+
+		if remove {
+			if err := delivery.Ack(false); err != nil {
+				return fmt.Errorf("erro ao remover mensagem da DLQ: %w", err)
+			}
+			continue
+		}
+		pending = append(pending, delivery)
+	}
+}
+
+func toDeadLetterMessage(delivery amqp.Delivery) models.DeadLetterMessage {
+	message := models.DeadLetterMessage{
+		MessageID: delivery.MessageId,
+		Attempts:  retryCount(delivery.Headers),
+	}
+
+	var orderMsg models.OrderMessage
+	if err := json.Unmarshal(delivery.Body, &orderMsg); err == nil {
+		message.Message = &orderMsg
+		message.OrderID = orderMsg.OrderID
+		if message.MessageID == "" {
+			message.MessageID = orderMsg.MessageID
+		}
+	} else {
+		message.Body = string(delivery.Body)
+	}
+
+	if reason, ok := delivery.Headers[headerFailureReason].(string); ok {
+		message.Reason = reason
+	}
+	if failedAt, ok := delivery.Headers[headerFailedAt].(string); ok {
+		if parsed, err := time.Parse(time.RFC3339, failedAt); err == nil {
+			message.FailedAt = &parsed
+		}
+	}
+
+	// Mensagens rejeitadas direto pela fila (sem passar pelo worker) só têm x-death.
+	if deaths, ok := delivery.Headers["x-death"].([]interface{}); ok && len(deaths) > 0 {
+		if death, ok := deaths[0].(amqp.Table); ok {
+			if message.Reason == "" {
+				if reason, ok := death["reason"].(string); ok {
+					message.Reason = "dead-lettered: " + reason
+				}
+			}
+			if message.FailedAt == nil {
+				if failedAt, ok := death["time"].(time.Time); ok {
+					message.FailedAt = &failedAt
+				}
+			}
+			if message.Attempts == 0 {
+				if count, ok := death["count"].(int64); ok {
+					message.Attempts = int(count)
+				}
+			}
+		}
+	}
+
+	return message
+}
@@ -3,21 +3,27 @@ package broker
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
+	"github.com/dev-bruno-arruda/api-pedidos/rabbitmq"
 	"github.com/dev-bruno-arruda/api-pedidos/worker_service/pkg/logger"
 	"github.com/dev-bruno-arruda/api-pedidos/worker_service/pkg/models"
 	"github.com/dev-bruno-arruda/api-pedidos/worker_service/pkg/ports"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
+var ErrMalformedMessage = errors.New("mensagem malformada")
+
 type RabbitMQConsumer struct {
-	conn      *amqp.Connection
-	channel   *amqp.Channel
-	queueName string
-	workers   int
+	connection    *rabbitmq.ConnectionManager
+	queueName     string
+	prefetchCount int
+	workers       int
+	maxAttempts   int
+	retryDelays   []time.Duration
 }
 
 type ConsumerConfig struct {
@@ -25,108 +31,124 @@ type ConsumerConfig struct {
 	QueueName     string
 	MaxRetries    int
 	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
 	PrefetchCount int
 	Workers       int
+	// MaxAttempts é o número de vezes que uma mensagem é processada antes de ir
+	// para a DLQ. RetryDelays define o backoff entre as tentativas; a partir da
+	// última posição o mesmo atraso é reutilizado.
+	MaxAttempts int
+	RetryDelays []time.Duration
 }
 
 func NewRabbitMQConsumer(config ConsumerConfig) (*RabbitMQConsumer, error) {
-	var conn *amqp.Connection
-	var err error
-
-	for i := 0; i < config.MaxRetries; i++ {
-		conn, err = amqp.Dial(config.URI)
-		if err == nil {
-			break
-		}
-		logger.Warnf("Tentativa %d de %d: Falha ao conectar ao RabbitMQ: %v", i+1, config.MaxRetries, err)
-		time.Sleep(config.RetryDelay)
+	consumer := &RabbitMQConsumer{
+		queueName:     config.QueueName,
+		prefetchCount: config.PrefetchCount,
+		workers:       config.Workers,
+		maxAttempts:   config.MaxAttempts,
+		retryDelays:   config.RetryDelays,
 	}
 
+	connection, err := rabbitmq.NewConnectionManager(rabbitmq.ConnectionConfig{
+		URI:           config.URI,
+		MaxRetries:    config.MaxRetries,
+		RetryDelay:    config.RetryDelay,
+		MaxRetryDelay: config.MaxRetryDelay,
+	}, consumer.setupChannel)
 	if err != nil {
-		return nil, fmt.Errorf("falha ao conectar ao RabbitMQ após %d tentativas: %w", config.MaxRetries, err)
+		return nil, err
 	}
+	consumer.connection = connection
 
-	channel, err := conn.Channel()
-	if err != nil {
-		conn.Close()
-		return nil, fmt.Errorf("falha ao abrir canal: %w", err)
+	logger.Info("Conectado ao RabbitMQ com sucesso")
+
+	return consumer, nil
+}
+
+// setupChannel é executado pelo ConnectionManager a cada (re)conexão.
+func (c *RabbitMQConsumer) setupChannel(channel *amqp.Channel) error {
+	if err := declareOrderQueue(channel, c.queueName); err != nil {
+		return err
 	}
 
-	_, err = channel.QueueDeclare(
-		config.QueueName,
-		true,  // durable
-		false, // delete when unused
-		false, // exclusive
-		false, // no-wait
-		nil,   // arguments
-	)
-	if err != nil {
-		channel.Close()
-		conn.Close()
-		return nil, fmt.Errorf("falha ao declarar fila: %w", err)
+	if err := declareRetryQueues(channel, c.queueName, c.retryDelays); err != nil {
+		return err
 	}
 
-	err = channel.Qos(
-		config.PrefetchCount,
+	// As republicações para retry e DLQ só confirmam a entrega original depois
+	// do ack do broker; sem isso uma publicação perdida perderia a mensagem.
+	if err := channel.Confirm(false); err != nil {
+		return fmt.Errorf("falha ao ativar modo de confirmação: %w", err)
+	}
+
+	err := channel.Qos(
+		c.prefetchCount,
 		0,     // prefetch size
 		false, // global
 	)
 	if err != nil {
-		channel.Close()
-		conn.Close()
-		return nil, fmt.Errorf("falha ao configurar QoS: %w", err)
+		return fmt.Errorf("falha ao configurar QoS: %w", err)
 	}
 
-	logger.Info("Conectado ao RabbitMQ com sucesso")
+	return nil
+}
 
-	return &RabbitMQConsumer{
-		conn:      conn,
-		channel:   channel,
-		queueName: config.QueueName,
-		workers:   config.Workers,
-	}, nil
+func (c *RabbitMQConsumer) State() rabbitmq.ConnectionState {
+	return c.connection.State()
 }
 
+// StartConsuming consome a fila até o contexto ser cancelado. Se a conexão cair,
+// os workers terminam as mensagens em andamento e o consumer é registrado
+// novamente assim que o ConnectionManager reconectar.
 func (c *RabbitMQConsumer) StartConsuming(ctx context.Context, handler ports.MessageHandler) error {
-	msgs, err := c.channel.Consume(
-		c.queueName,
-		"",    // consumer
-		false, // auto-ack
-		false, // exclusive
-		false, // no-local
-		false, // no-wait
-		nil,   // args
-	)
-	if err != nil {
-		return fmt.Errorf("falha ao registrar consumer: %w", err)
-	}
-
-	logger.Infof("Iniciando %d workers para processar mensagens...", c.workers)
+	for {
+		channel, err := c.connection.WaitChannel(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("conexão com RabbitMQ perdida: %w", err)
+		}
 
-	var wg sync.WaitGroup
-	notifyClose := make(chan *amqp.Error)
-	c.channel.NotifyClose(notifyClose)
+		msgs, err := channel.Consume(
+			c.queueName,
+			"",    // consumer
+			false, // auto-ack
+			false, // exclusive
+			false, // no-local
+			false, // no-wait
+			nil,   // args
+		)
+		if err != nil {
+			logger.Errorf("Falha ao registrar consumer, aguardando reconexão: %v", err)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Second):
+			}
+			continue
+		}
 
-	for i := 0; i < c.workers; i++ {
-		wg.Add(1)
-		go c.worker(ctx, i, msgs, handler, &wg)
-	}
+		logger.Infof("Iniciando %d workers para processar mensagens...", c.workers)
 
-	select {
-	case <-ctx.Done():
-		logger.Info("Context cancelado, aguardando workers finalizarem...")
+		var wg sync.WaitGroup
+		for i := 0; i < c.workers; i++ {
+			wg.Add(1)
+			go c.worker(ctx, i, channel, msgs, handler, &wg)
+		}
 		wg.Wait()
-		logger.Info("Todos os workers finalizados")
-		return ctx.Err()
 
-	case err := <-notifyClose:
-		logger.Errorf("Conexão com RabbitMQ fechada: %v", err)
-		wg.Wait()
-		return fmt.Errorf("conexão com RabbitMQ perdida: %w", err)
+		if ctx.Err() != nil {
+			logger.Info("Todos os workers finalizados")
+			return ctx.Err()
+		}
+
+		logger.Warn("Canal de mensagens fechado, aguardando reconexão para registrar o consumer novamente")
 	}
 }
 
-func (c *RabbitMQConsumer) worker(ctx context.Context, id int, msgs <-chan amqp.Delivery, handler ports.MessageHandler, wg *sync.WaitGroup) {
+func (c *RabbitMQConsumer) worker(ctx context.Context, id int, channel *amqp.Channel, msgs <-chan amqp.Delivery, handler ports.MessageHandler, wg *sync.WaitGroup) {
 	defer wg.Done()
 	logger.WorkerInfo(id, "iniciado")
 
@@ -145,8 +167,15 @@ func (c *RabbitMQConsumer) worker(ctx context.Context, id int, msgs <-chan amqp.
 			logger.WorkerInfof(id, "processando mensagem: OrderID=%s", extractOrderID(msg.Body))
 
 			if err := c.processMessage(ctx, msg, handler); err != nil {
+				if ctx.Err() != nil {
+					// Interrompida pelo shutdown: volta para a fila sem contar
+					// como tentativa.
+					logger.WorkerInfof(id, "processamento interrompido pelo shutdown, devolvendo mensagem: %v", err)
+					msg.Nack(false, true)
+					return
+				}
 				logger.WorkerErrorf(id, "erro ao processar mensagem: %v", err)
-				msg.Nack(false, true)
+				c.handleFailure(id, channel, msg, err)
 			} else {
 				msg.Ack(false)
 				logger.WorkerInfo(id, "mensagem processada com sucesso")
@@ -168,7 +197,11 @@ func (c *RabbitMQConsumer) processMessage(ctx context.Context, msg amqp.Delivery
 
 	err := json.Unmarshal(msg.Body, &orderMsg)
 	if err != nil {
-		return fmt.Errorf("erro ao deserializar mensagem: %w", err)
+		return fmt.Errorf("%w: %v", ErrMalformedMessage, err)
+	}
+
+	if orderMsg.MessageID == "" {
+		orderMsg.MessageID = msg.MessageId
 	}
 
 	logger.Infof("Mensagem recebida: OrderID=%s, Status=%s", orderMsg.OrderID, orderMsg.Status)
@@ -181,16 +214,83 @@ func (c *RabbitMQConsumer) processMessage(ctx context.Context, msg amqp.Delivery
 	return nil
 }
 
-func (c *RabbitMQConsumer) Close() error {
-	if c.channel != nil {
-		if err := c.channel.Close(); err != nil {
-			return err
+// handleFailure decide o destino de uma mensagem que falhou: mensagens
+// malformadas e mensagens que esgotaram as tentativas vão para a DLQ, as demais
+// são republicadas em uma fila de retry com TTL.
+func (c *RabbitMQConsumer) handleFailure(workerID int, channel *amqp.Channel, msg amqp.Delivery, cause error) {
+	attempts := retryCount(msg.Headers) + 1
+
+	if errors.Is(cause, ErrMalformedMessage) || attempts >= c.maxAttempts {
+		if err := c.deadLetter(channel, msg, attempts, cause); err != nil {
+			// Sem a confirmação da DLQ a mensagem volta para a fila e é tentada
+			// de novo, em vez de ser confirmada sem destino garantido.
+			logger.WorkerErrorf(workerID, "erro ao publicar na DLQ, devolvendo mensagem para a fila: %v", err)
+			msg.Nack(false, true)
+			return
 		}
+		msg.Ack(false)
+		logger.WorkerErrorf(workerID, "mensagem enviada para a DLQ após %d tentativa(s): OrderID=%s", attempts, extractOrderID(msg.Body))
+		return
 	}
-	if c.conn != nil {
-		if err := c.conn.Close(); err != nil {
-			return err
-		}
+
+	if len(c.retryDelays) == 0 {
+		msg.Nack(false, true)
+		return
+	}
+
+	level := attempts - 1
+	if level >= len(c.retryDelays) {
+		level = len(c.retryDelays) - 1
+	}
+
+	if err := c.republish(channel, "", retryQueueName(c.queueName, level), msg, attempts, cause); err != nil {
+		logger.WorkerErrorf(workerID, "erro ao agendar retry, devolvendo mensagem para a fila: %v", err)
+		msg.Nack(false, true)
+		return
+	}
+	msg.Ack(false)
+	logger.WorkerInfof(workerID, "retry %d de %d agendado em %s: OrderID=%s",
+		attempts, c.maxAttempts-1, c.retryDelays[level], extractOrderID(msg.Body))
+}
+
+func (c *RabbitMQConsumer) deadLetter(channel *amqp.Channel, msg amqp.Delivery, attempts int, cause error) error {
+	return c.republish(channel, DeadLetterExchangeName(c.queueName), c.queueName, msg, attempts, cause)
+}
+
+func (c *RabbitMQConsumer) republish(channel *amqp.Channel, exchange, routingKey string, msg amqp.Delivery, attempts int, cause error) error {
+	headers := amqp.Table{}
+	for key, value := range msg.Headers {
+		headers[key] = value
+	}
+	headers[headerRetryCount] = int32(attempts)
+	headers[headerFailureReason] = cause.Error()
+	headers[headerFailedAt] = time.Now().UTC().Format(time.RFC3339)
+	headers[headerOriginalQueue] = c.queueName
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	confirmation, err := channel.PublishWithDeferredConfirmWithContext(ctx, exchange, routingKey, false, false, amqp.Publishing{
+		Headers:      headers,
+		ContentType:  msg.ContentType,
+		MessageId:    msg.MessageId,
+		Body:         msg.Body,
+		DeliveryMode: amqp.Persistent,
+	})
+	if err != nil {
+		return err
+	}
+
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("erro ao aguardar confirmação do broker: %w", err)
+	}
+	if !acked {
+		return errors.New("broker rejeitou a republicação")
 	}
 	return nil
 }
+
+func (c *RabbitMQConsumer) Close() error {
+	return c.connection.Close()
+}
@@ -0,0 +1,112 @@
+package broker
+
+import (
+	"fmt"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	headerRetryCount    = "x-retry-count"
+	headerFailureReason = "x-failure-reason"
+	headerFailedAt      = "x-failed-at"
+	headerOriginalQueue = "x-original-queue"
+)
+
+func DeadLetterExchangeName(queueName string) string {
+	return queueName + ".dlx"
+}
+
+func DeadLetterQueueName(queueName string) string {
+	return queueName + ".dlq"
+}
+
+func retryQueueName(queueName string, level int) string {
+	return fmt.Sprintf("%s.retry.%d", queueName, level+1)
+}
+
+// declareOrderQueue declara a fila principal com dead-letter exchange e a DLQ
+// ligada a ela. A API declara a mesma topologia, e os argumentos precisam ser
+// idênticos nos dois serviços.
+func declareOrderQueue(channel *amqp.Channel, queueName string) error {
+	dlx := DeadLetterExchangeName(queueName)
+	dlq := DeadLetterQueueName(queueName)
+
+	err := channel.ExchangeDeclare(
+		dlx,
+		amqp.ExchangeDirect,
+		true,  // durable
+		false, // auto-deleted
+		false, // internal
+		false, // no-wait
+		nil,   // arguments
+	)
+	if err != nil {
+		return fmt.Errorf("falha ao declarar dead-letter exchange: %w", err)
+	}
+
+	_, err = channel.QueueDeclare(dlq, true, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("falha ao declarar DLQ: %w", err)
+	}
+
+	err = channel.QueueBind(dlq, queueName, dlx, false, nil)
+	if err != nil {
+		return fmt.Errorf("falha ao vincular DLQ: %w", err)
+	}
+
+	_, err = channel.QueueDeclare(
+		queueName,
+		true,  // durable
+		false, // delete when unused
+		false, // exclusive
+		false, // no-wait
+		amqp.Table{
+			"x-dead-letter-exchange":    dlx,
+			"x-dead-letter-routing-key": queueName,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("falha ao declarar fila: %w", err)
+	}
+
+	return nil
+}
+
+// declareRetryQueues declara uma fila de espera por nível de backoff. As
+// mensagens ficam na fila até o TTL expirar e então voltam para a fila principal
+// pelo default exchange.
+func declareRetryQueues(channel *amqp.Channel, queueName string, delays []time.Duration) error {
+	for level, delay := range delays {
+		_, err := channel.QueueDeclare(
+			retryQueueName(queueName, level),
+			true,  // durable
+			false, // delete when unused
+			false, // exclusive
+			false, // no-wait
+			amqp.Table{
+				"x-message-ttl":             delay.Milliseconds(),
+				"x-dead-letter-exchange":    "",
+				"x-dead-letter-routing-key": queueName,
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("falha ao declarar fila de retry %s: %w", retryQueueName(queueName, level), err)
+		}
+	}
+
+	return nil
+}
+
+func retryCount(headers amqp.Table) int {
+	switch value := headers[headerRetryCount].(type) {
+	case int32:
+		return int(value)
+	case int64:
+		return int(value)
+	case int:
+		return value
+	}
+	return 0
+}
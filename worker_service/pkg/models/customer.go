@@ -0,0 +1,12 @@
+package models
+
+import "errors"
+
+var ErrCustomerNotFound = errors.New("cliente não encontrado")
+
+// Customer é a parte do cadastro de clientes da API usada no processamento.
+type Customer struct {
+	CustomerID string `bson:"customer_id" json:"customer_id"`
+	Name       string `bson:"name" json:"name"`
+	Email      string `bson:"email" json:"email"`
+}
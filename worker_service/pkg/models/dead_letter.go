@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+type DeadLetterMessage struct {
+	MessageID string        `json:"message_id"`
+	OrderID   string        `json:"order_id"`
+	Message   *OrderMessage `json:"message,omitempty"`
+	Body      string        `json:"body,omitempty"`
+	Reason    string        `json:"reason"`
+	Attempts  int           `json:"attempts"`
+	FailedAt  *time.Time    `json:"failed_at,omitempty"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrInsufficientStock = errors.New("estoque insuficiente")
+	ErrProductNotFound   = errors.New("produto não encontrado no catálogo")
+)
+
+const (
+	ReservationStatusReserved = "RESERVADO"
+	ReservationStatusReleased = "LIBERADO"
+)
+
+// Product é um item do catálogo. Stock é a quantidade disponível, já
+// descontadas as reservas.
+type Product struct {
+	SKU       string    `bson:"_id" json:"sku"`
+	Name      string    `bson:"name" json:"name"`
+	Stock     int       `bson:"stock" json:"stock"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+type ReservationItem struct {
+	SKU      string `bson:"sku" json:"sku"`
+	Quantity int    `bson:"quantity" json:"quantity"`
+}
+
+// Reservation registra o estoque reservado para um pedido, para que a reserva
+// não seja repetida em uma nova entrega e possa ser devolvida no cancelamento.
+type Reservation struct {
+	OrderID    string            `bson:"_id" json:"order_id"`
+	Items      []ReservationItem `bson:"items" json:"items"`
+	Status     string            `bson:"status" json:"status"`
+	CreatedAt  time.Time         `bson:"created_at" json:"created_at"`
+	ReleasedAt *time.Time        `bson:"released_at,omitempty" json:"released_at,omitempty"`
+}
+
+// StockError informa qual item impediu a reserva. errors.Is funciona com
+// ErrInsufficientStock ou ErrProductNotFound.
+type StockError struct {
+	SKU       string
+	Requested int
+	Available int
+	Err       error
+}
+
+func (e *StockError) Error() string {
+	if errors.Is(e.Err, ErrProductNotFound) {
+		return fmt.Sprintf("%v: SKU %s", e.Err, e.SKU)
+	}
+	return fmt.Sprintf("%v para SKU %s (solicitado %d, disponível %d)", e.Err, e.SKU, e.Requested, e.Available)
+}
+
+func (e *StockError) Unwrap() error {
+	return e.Err
+}
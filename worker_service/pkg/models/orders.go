@@ -1,28 +1,71 @@
 package models
 
 import (
+	"errors"
 	"time"
 
+	"github.com/dev-bruno-arruda/api-pedidos/domain"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-const (
-	StatusCriado      = "CRIADO"
-	StatusProcessando = "PROCESSANDO"
-	StatusProcessado  = "PROCESSADO"
+var (
+	ErrOrderNotFound = errors.New("pedido não encontrado")
+	// ErrSagaLeaseHeld indica que outra execução está com a saga do pedido.
+	ErrSagaLeaseHeld = errors.New("saga do pedido em execução por outro worker")
 )
 
 type Order struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	OrderID   string             `bson:"order_id" json:"order_id"`
-	Product   string             `bson:"product" json:"product"`
-	Quantity  int                `bson:"quantity" json:"quantity"`
-	Status    string             `bson:"status" json:"status"`
-	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+	ID         primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	OrderID    string              `bson:"order_id" json:"order_id"`
+	CustomerID string              `bson:"customer_id,omitempty" json:"customer_id,omitempty"`
+	Items      []domain.OrderItem  `bson:"items" json:"items"`
+	Totals     domain.OrderTotals  `bson:"totals" json:"totals"`
+	Status     domain.Status       `bson:"status" json:"status"`
+	CreatedAt  time.Time           `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time           `bson:"updated_at" json:"updated_at"`
+	Payment    *domain.Payment     `bson:"payment,omitempty" json:"payment,omitempty"`
+	Saga       *domain.SagaState   `bson:"saga,omitempty" json:"saga,omitempty"`
+	Steps      []domain.StepRecord `bson:"steps,omitempty" json:"steps,omitempty"`
+}
+
+func (o *Order) StepSucceeded(name string) bool {
+	for _, step := range o.Steps {
+		if step.Name == name {
+			return step.Outcome == domain.StepSucceeded
+		}
+	}
+	return false
+}
+
+type legacyOrderFields struct {
+	Product  string `bson:"product"`
+	Quantity int    `bson:"quantity"`
+}
+
+// UnmarshalBSON converte os pedidos antigos, gravados com product/quantity, em
+// um pedido com um único item.
+func (o *Order) UnmarshalBSON(data []byte) error {
+	type order Order
+	if err := bson.Unmarshal(data, (*order)(o)); err != nil {
+		return err
+	}
+
+	if len(o.Items) == 0 {
+		var legacy legacyOrderFields
+		if err := bson.Unmarshal(data, &legacy); err != nil {
+			return err
+		}
+		if legacy.Product != "" {
+			o.Items = []domain.OrderItem{domain.LegacyOrderItem(legacy.Product, legacy.Quantity)}
+		}
+	}
+
+	return nil
 }
 
 type OrderMessage struct {
-	OrderID string `json:"order_id"`
-	Status  string `json:"status"`
+	MessageID string        `json:"message_id,omitempty"`
+	OrderID   string        `json:"order_id"`
+	Status    domain.Status `json:"status"`
 }
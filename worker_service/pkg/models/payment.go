@@ -0,0 +1,10 @@
+package models
+
+import "errors"
+
+var (
+	// ErrPaymentDeclined é uma recusa definitiva do gateway; repetir a operação
+	// não muda o resultado.
+	ErrPaymentDeclined = errors.New("pagamento recusado")
+	ErrPaymentTimeout  = errors.New("tempo esgotado aguardando o gateway de pagamento")
+)
@@ -0,0 +1,106 @@
+package payment
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dev-bruno-arruda/api-pedidos/domain"
+	"github.com/dev-bruno-arruda/api-pedidos/worker_service/pkg/models"
+)
+
+type FakeMode string
+
+const (
+	FakeApprove FakeMode = "approve"
+	FakeDecline FakeMode = "decline"
+	FakeTimeout FakeMode = "timeout"
+)
+
+func ParseFakeMode(value string) (FakeMode, error) {
+	switch mode := FakeMode(strings.ToLower(value)); mode {
+	case FakeApprove, FakeDecline, FakeTimeout:
+		return mode, nil
+	}
+	return "", fmt.Errorf("modo do gateway fake inválido: %q (use approve, decline ou timeout)", value)
+}
+
+// FakeGateway é um gateway em memória para desenvolvimento e testes. É
+// determinístico: os identificadores são derivados do pedido ou da transação
+// anterior, então repetir uma chamada devolve o mesmo identificador.
+//
+// No modo decline apenas Authorize é recusada; no modo timeout todas as
+// chamadas bloqueiam até o contexto expirar.
+type FakeGateway struct {
+	mode    FakeMode
+	latency time.Duration
+}
+
+func NewFakeGateway(mode FakeMode, latency time.Duration) *FakeGateway {
+	return &FakeGateway{mode: mode, latency: latency}
+}
+
+func (g *FakeGateway) Authorize(ctx context.Context, orderID string, amount domain.Money) (string, error) {
+	if err := g.wait(ctx); err != nil {
+		return "", err
+	}
+	if amount.IsNegative() {
+		return "", fmt.Errorf("%w: valor inválido %s", models.ErrPaymentDeclined, amount)
+	}
+	if g.mode == FakeDecline {
+		return "", fmt.Errorf("%w: transação negada pelo emissor", models.ErrPaymentDeclined)
+	}
+	return transactionID("auth", orderID), nil
+}
+
+func (g *FakeGateway) Capture(ctx context.Context, authorizationID string, amount domain.Money) (string, error) {
+	if err := g.wait(ctx); err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(authorizationID, "auth_") {
+		return "", fmt.Errorf("%w: autorização desconhecida %q", models.ErrPaymentDeclined, authorizationID)
+	}
+	return transactionID("cap", authorizationID), nil
+}
+
+func (g *FakeGateway) Refund(ctx context.Context, captureID string, amount domain.Money) (string, error) {
+	if err := g.wait(ctx); err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(captureID, "cap_") {
+		return "", fmt.Errorf("%w: captura desconhecida %q", models.ErrPaymentDeclined, captureID)
+	}
+	return transactionID("ref", captureID), nil
+}
+
+func (g *FakeGateway) Void(ctx context.Context, authorizationID string, amount domain.Money) (string, error) {
+	if err := g.wait(ctx); err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(authorizationID, "auth_") {
+		return "", fmt.Errorf("%w: autorização desconhecida %q", models.ErrPaymentDeclined, authorizationID)
+	}
+	return transactionID("void", authorizationID), nil
+}
+
+func (g *FakeGateway) wait(ctx context.Context) error {
+	var delay <-chan time.Time
+	if g.mode != FakeTimeout {
+		delay = time.After(g.latency)
+	}
+
+	select {
+	case <-delay:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %v", models.ErrPaymentTimeout, ctx.Err())
+	}
+}
+
+func transactionID(prefix, seed string) string {
+	sum := sha256.Sum256([]byte(prefix + ":" + seed))
+	return prefix + "_" + hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,11 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/dev-bruno-arruda/api-pedidos/worker_service/pkg/models"
+)
+
+type CustomerRepository interface {
+	FindByCustomerID(ctx context.Context, customerID string) (*models.Customer, error)
+}
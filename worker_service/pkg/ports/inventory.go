@@ -0,0 +1,16 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/dev-bruno-arruda/api-pedidos/worker_service/pkg/models"
+)
+
+type InventoryRepository interface {
+	// Reserve reserva todos os itens ou nenhum. Falta de estoque retorna um
+	// *models.StockError; reservar de novo o mesmo pedido não tem efeito.
+	Reserve(ctx context.Context, orderID string, items []models.ReservationItem) error
+	// Release devolve o estoque reservado para o pedido. Retorna false se não
+	// havia reserva ativa.
+	Release(ctx context.Context, orderID string) (bool, error)
+}
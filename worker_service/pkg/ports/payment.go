@@ -0,0 +1,21 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/dev-bruno-arruda/api-pedidos/domain"
+)
+
+// PaymentGateway é o provedor de pagamentos. Recusas retornam um erro que
+// satisfaz errors.Is(err, models.ErrPaymentDeclined); demais erros são tratados
+// como temporários.
+//
+// Authorize deve ser idempotente por orderID, pois pode ser repetida quando a
+// gravação da autorização no pedido falha. Void cancela uma autorização que
+// não foi capturada, liberando o valor reservado.
+type PaymentGateway interface {
+	Authorize(ctx context.Context, orderID string, amount domain.Money) (authorizationID string, err error)
+	Capture(ctx context.Context, authorizationID string, amount domain.Money) (captureID string, err error)
+	Refund(ctx context.Context, captureID string, amount domain.Money) (refundID string, err error)
+	Void(ctx context.Context, authorizationID string, amount domain.Money) (voidID string, err error)
+}
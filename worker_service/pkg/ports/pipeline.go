@@ -0,0 +1,24 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/dev-bruno-arruda/api-pedidos/worker_service/pkg/models"
+)
+
+// OrderStep é uma etapa do processamento do pedido, executada como parte de uma
+// saga. Execute retorna nil em caso de sucesso; erros marcados com
+// models.Permanent disparam as compensações e encerram o pedido como REJEITADO,
+// e os demais fazem a mensagem ser entregue de novo.
+//
+// Etapas concluídas não são executadas outra vez na mesma entrega nem nas
+// seguintes, mas Execute deve tolerar ser repetida após uma falha temporária.
+//
+// Compensate desfaz uma execução concluída ou os efeitos parciais de uma que
+// falhou. É chamada em ordem inversa após uma falha permanente ou um
+// cancelamento, e pode ser repetida se falhar.
+type OrderStep interface {
+	Name() string
+	Execute(ctx context.Context, order *models.Order) error
+	Compensate(ctx context.Context, order *models.Order) error
+}
@@ -0,0 +1,10 @@
+package ports
+
+import "context"
+
+// ProcessedMessageStore registra as mensagens já processadas para que entregas
+// repetidas do broker sejam reconhecidas e confirmadas sem efeitos colaterais.
+type ProcessedMessageStore interface {
+	IsProcessed(ctx context.Context, messageID string) (bool, error)
+	MarkProcessed(ctx context.Context, messageID, orderID string) error
+}
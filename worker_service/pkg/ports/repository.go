@@ -2,11 +2,26 @@ package ports
 
 import (
 	"context"
+	"time"
 
+	"github.com/dev-bruno-arruda/api-pedidos/domain"
 	"github.com/dev-bruno-arruda/api-pedidos/worker_service/pkg/models"
 )
 
 type OrderRepository interface {
-	UpdateStatus(ctx context.Context, orderID string, status string) error
+	UpdateStatus(ctx context.Context, orderID string, change domain.StatusChange) error
 	FindByOrderID(ctx context.Context, orderID string) (*models.Order, error)
+	// RecordStep grava o resultado de uma etapa e incrementa suas tentativas.
+	RecordStep(ctx context.Context, orderID string, record domain.StepRecord) error
+	RecordCompensation(ctx context.Context, orderID, step string, outcome domain.StepOutcome, errMsg string) error
+	UpdateSaga(ctx context.Context, orderID string, saga domain.SagaState) error
+	UpdatePayment(ctx context.Context, orderID string, payment domain.Payment) error
+	// AcquireLease reserva a saga do pedido para owner até ttl, ou renova a
+	// reserva se ela já for de owner. Devolve models.ErrSagaLeaseHeld se outra
+	// execução tiver uma reserva válida.
+	AcquireLease(ctx context.Context, orderID, owner string, ttl time.Duration) error
+	ReleaseLease(ctx context.Context, orderID, owner string) error
+	// FindStuckSagas busca pedidos em PROCESSANDO cuja saga não avança desde
+	// antes de cutoff e que não estão reservados.
+	FindStuckSagas(ctx context.Context, cutoff time.Time, limit int64) ([]models.Order, error)
 }
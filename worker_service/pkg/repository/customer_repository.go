@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dev-bruno-arruda/api-pedidos/worker_service/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CustomerRepository lê o cadastro de clientes mantido pela API.
+type CustomerRepository struct {
+	collection *mongo.Collection
+}
+
+func NewCustomerRepository(client *mongo.Client, dbName, collectionName string) *CustomerRepository {
+	return &CustomerRepository{
+		collection: client.Database(dbName).Collection(collectionName),
+	}
+}
+
+func (r *CustomerRepository) FindByCustomerID(ctx context.Context, customerID string) (*models.Customer, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var customer models.Customer
+	err := r.collection.FindOne(ctx, bson.M{"customer_id": customerID}).Decode(&customer)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("%w: %s", models.ErrCustomerNotFound, customerID)
+		}
+		return nil, fmt.Errorf("erro ao buscar cliente: %w", err)
+	}
+
+	return &customer, nil
+}
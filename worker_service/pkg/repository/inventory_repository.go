@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dev-bruno-arruda/api-pedidos/worker_service/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// InventoryRepository baixa e devolve estoque dentro de transações, junto com
+// o registro da reserva. Transações exigem que o MongoDB rode como replica set.
+type InventoryRepository struct {
+	client       *mongo.Client
+	products     *mongo.Collection
+	reservations *mongo.Collection
+}
+
+func NewInventoryRepository(client *mongo.Client, dbName, productsCollection, reservationsCollection string) *InventoryRepository {
+	db := client.Database(dbName)
+	return &InventoryRepository{
+		client:       client,
+		products:     db.Collection(productsCollection),
+		reservations: db.Collection(reservationsCollection),
+	}
+}
+
+func (r *InventoryRepository) Reserve(ctx context.Context, orderID string, items []models.ReservationItem) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return r.withTransaction(ctx, func(ctx mongo.SessionContext) error {
+		count, err := r.reservations.CountDocuments(ctx, bson.M{"_id": orderID})
+		if err != nil {
+			return fmt.Errorf("erro ao verificar reserva: %w", err)
+		}
+		if count > 0 {
+			return nil
+		}
+
+		now := time.Now()
+		for _, item := range items {
+			// O filtro por stock >= quantidade torna a baixa condicional: dois
+			// workers nunca reservam a mesma unidade.
+			result, err := r.products.UpdateOne(ctx,
+				bson.M{"_id": item.SKU, "stock": bson.M{"$gte": item.Quantity}},
+				bson.M{"$inc": bson.M{"stock": -item.Quantity}, "$set": bson.M{"updated_at": now}},
+			)
+			if err != nil {
+				return fmt.Errorf("erro ao reservar estoque do SKU %s: %w", item.SKU, err)
+			}
+			if result.MatchedCount == 0 {
+				return r.stockError(ctx, item)
+			}
+		}
+
+		_, err = r.reservations.InsertOne(ctx, models.Reservation{
+			OrderID:   orderID,
+			Items:     items,
+			Status:    models.ReservationStatusReserved,
+			CreatedAt: now,
+		})
+		if err != nil {
+			return fmt.Errorf("erro ao registrar reserva: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *InventoryRepository) Release(ctx context.Context, orderID string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	released := false
+	err := r.withTransaction(ctx, func(ctx mongo.SessionContext) error {
+		released = false
+		now := time.Now()
+
+		var reservation models.Reservation
+		err := r.reservations.FindOneAndUpdate(ctx,
+			bson.M{"_id": orderID, "status": models.ReservationStatusReserved},
+			bson.M{"$set": bson.M{"status": models.ReservationStatusReleased, "released_at": now}},
+		).Decode(&reservation)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				return nil
+			}
+			return fmt.Errorf("erro ao buscar reserva: %w", err)
+		}
+
+		for _, item := range reservation.Items {
+			_, err := r.products.UpdateOne(ctx,
+				bson.M{"_id": item.SKU},
+				bson.M{"$inc": bson.M{"stock": item.Quantity}, "$set": bson.M{"updated_at": now}},
+			)
+			if err != nil {
+				return fmt.Errorf("erro ao devolver estoque do SKU %s: %w", item.SKU, err)
+			}
+		}
+
+		released = true
+		return nil
+	})
+	return released, err
+}
+
+func (r *InventoryRepository) FindProducts(ctx context.Context) ([]models.Product, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	cur, err := r.products.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar produtos: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	var products []models.Product
+	if err := cur.All(ctx, &products); err != nil {
+		return nil, fmt.Errorf("erro ao decodificar produtos: %w", err)
+	}
+	return products, nil
+}
+
+// SetProduct cadastra o produto ou substitui nome e estoque disponível.
+func (r *InventoryRepository) SetProduct(ctx context.Context, product models.Product) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	product.UpdatedAt = time.Now()
+	_, err := r.products.ReplaceOne(ctx, bson.M{"_id": product.SKU}, product, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("erro ao salvar produto %s: %w", product.SKU, err)
+	}
+	return nil
+}
+
+func (r *InventoryRepository) stockError(ctx context.Context, item models.ReservationItem) error {
+	var product models.Product
+	err := r.products.FindOne(ctx, bson.M{"_id": item.SKU}).Decode(&product)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return &models.StockError{SKU: item.SKU, Requested: item.Quantity, Err: models.ErrProductNotFound}
+		}
+		return fmt.Errorf("erro ao consultar estoque do SKU %s: %w", item.SKU, err)
+	}
+	return &models.StockError{SKU: item.SKU, Requested: item.Quantity, Available: product.Stock, Err: models.ErrInsufficientStock}
+}
+
+func (r *InventoryRepository) withTransaction(ctx context.Context, fn func(ctx mongo.SessionContext) error) error {
+	session, err := r.client.StartSession()
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar sessão no MongoDB: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessCtx)
+	})
+	return err
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/dev-bruno-arruda/api-pedidos/domain"
 	"github.com/dev-bruno-arruda/api-pedidos/worker_service/pkg/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -21,15 +22,27 @@ func NewOrderRepository(client *mongo.Client, dbName, collectionName string) *Or
 	}
 }
 
-func (r *OrderRepository) UpdateStatus(ctx context.Context, orderID string, status string) error {
+func (r *OrderRepository) UpdateStatus(ctx context.Context, orderID string, change domain.StatusChange) error {
+	if err := domain.ValidateTransition(change.From, change.To); err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	filter := bson.M{"order_id": orderID}
-	update := bson.M{"$set": bson.M{
-		"status":     status,
-		"updated_at": time.Now(),
-	}}
+	now := time.Now()
+
+	// O filtro pelo status esperado torna a transição atômica: se a API cancelou
+	// o pedido nesse meio tempo, nenhum documento é atualizado. A entrada do
+	// histórico é gravada na mesma operação.
+	filter := bson.M{"order_id": orderID, "status": change.From}
+	update := bson.M{
+		"$set": bson.M{
+			"status":     change.To,
+			"updated_at": now,
+		},
+		"$push": bson.M{"status_history": change.Entry(now)},
+	}
 
 	result, err := r.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
@@ -37,7 +50,16 @@ func (r *OrderRepository) UpdateStatus(ctx context.Context, orderID string, stat
 	}
 
 	if result.MatchedCount == 0 {
-		return fmt.Errorf("pedido não encontrado: %s", orderID)
+		var current models.Order
+		err := r.collection.FindOne(ctx, bson.M{"order_id": orderID},
+			options.FindOne().SetProjection(bson.M{"status": 1})).Decode(&current)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				return fmt.Errorf("%w: %s", models.ErrOrderNotFound, orderID)
+			}
+			return fmt.Errorf("erro ao verificar status do pedido: %w", err)
+		}
+		return &domain.TransitionError{OrderID: orderID, From: change.From, To: change.To, Current: current.Status}
 	}
 
 	return nil
@@ -53,7 +75,7 @@ func (r *OrderRepository) FindByOrderID(ctx context.Context, orderID string) (*m
 	err := r.collection.FindOne(ctx, filter).Decode(&order)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("pedido não encontrado: %s", orderID)
+			return nil, fmt.Errorf("%w: %s", models.ErrOrderNotFound, orderID)
 		}
 		return nil, fmt.Errorf("erro ao buscar pedido: %w", err)
 	}
@@ -61,6 +83,116 @@ func (r *OrderRepository) FindByOrderID(ctx context.Context, orderID string) (*m
 	return &order, nil
 }
 
+// RecordStep atualiza a etapa já registrada no pedido ou a acrescenta ao final
+// de steps, preservando a ordem de execução.
+func (r *OrderRepository) RecordStep(ctx context.Context, orderID string, record domain.StepRecord) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	for {
+		result, err := r.collection.UpdateOne(ctx,
+			bson.M{"order_id": orderID, "steps.name": record.Name},
+			bson.M{
+				"$set": bson.M{
+					"steps.$.outcome":    record.Outcome,
+					"steps.$.error":      record.Error,
+					"steps.$.updated_at": record.UpdatedAt,
+					"updated_at":         record.UpdatedAt,
+				},
+				"$inc": bson.M{"steps.$.attempts": 1},
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao registrar etapa %s do pedido: %w", record.Name, err)
+		}
+		if result.MatchedCount > 0 {
+			return nil
+		}
+
+		record.Attempts = 1
+		result, err = r.collection.UpdateOne(ctx,
+			bson.M{"order_id": orderID, "steps.name": bson.M{"$ne": record.Name}},
+			bson.M{
+				"$push": bson.M{"steps": record},
+				"$set":  bson.M{"updated_at": record.UpdatedAt},
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao registrar etapa %s do pedido: %w", record.Name, err)
+		}
+		if result.MatchedCount > 0 {
+			return nil
+		}
+
+		// Nenhum dos filtros casou: ou o pedido não existe, ou outra entrega
+		// acrescentou a etapa entre as duas operações.
+		count, err := r.collection.CountDocuments(ctx, bson.M{"order_id": orderID})
+		if err != nil {
+			return fmt.Errorf("erro ao verificar pedido: %w", err)
+		}
+		if count == 0 {
+			return fmt.Errorf("%w: %s", models.ErrOrderNotFound, orderID)
+		}
+	}
+}
+
+func (r *OrderRepository) RecordCompensation(ctx context.Context, orderID, step string, outcome domain.StepOutcome, errMsg string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"order_id": orderID, "steps.name": step},
+		bson.M{"$set": bson.M{
+			"steps.$.compensation":       outcome,
+			"steps.$.compensation_error": errMsg,
+			"steps.$.compensated_at":     now,
+			"updated_at":                 now,
+		}},
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao registrar compensação da etapa %s: %w", step, err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: %s (etapa %s)", models.ErrOrderNotFound, orderID, step)
+	}
+	return nil
+}
+
+func (r *OrderRepository) UpdateSaga(ctx context.Context, orderID string, saga domain.SagaState) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"order_id": orderID},
+		bson.M{"$set": bson.M{"saga": saga, "updated_at": saga.UpdatedAt}},
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar saga do pedido: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: %s", models.ErrOrderNotFound, orderID)
+	}
+	return nil
+}
+
+func (r *OrderRepository) UpdatePayment(ctx context.Context, orderID string, payment domain.Payment) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"order_id": orderID},
+		bson.M{"$set": bson.M{"payment": payment, "updated_at": payment.UpdatedAt}},
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar pagamento do pedido: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: %s", models.ErrOrderNotFound, orderID)
+	}
+	return nil
+}
+
 type MongoDBConfig struct {
 	URI             string
 	Database        string
@@ -94,3 +226,82 @@ func ConnectMongoDB(ctx context.Context, config MongoDBConfig) (*mongo.Client, e
 
 	return client, nil
 }
+
+// AcquireLease usa o filtro do UpdateOne como trava: a reserva só é gravada se
+// não existir, se tiver expirado ou se já pertencer a owner.
+func (r *OrderRepository) AcquireLease(ctx context.Context, orderID, owner string, ttl time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	filter := bson.M{
+		"order_id": orderID,
+		"$or": bson.A{
+			bson.M{"saga_lease": bson.M{"$exists": false}},
+			bson.M{"saga_lease.expires_at": bson.M{"$lte": now}},
+			bson.M{"saga_lease.owner": owner},
+		},
+	}
+	update := bson.M{"$set": bson.M{
+		"saga_lease": bson.M{"owner": owner, "expires_at": now.Add(ttl)},
+	}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("erro ao reservar saga do pedido: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		count, err := r.collection.CountDocuments(ctx, bson.M{"order_id": orderID})
+		if err != nil {
+			return fmt.Errorf("erro ao verificar pedido: %w", err)
+		}
+		if count == 0 {
+			return fmt.Errorf("%w: %s", models.ErrOrderNotFound, orderID)
+		}
+		return fmt.Errorf("%w: %s", models.ErrSagaLeaseHeld, orderID)
+	}
+
+	return nil
+}
+
+func (r *OrderRepository) ReleaseLease(ctx context.Context, orderID, owner string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"order_id": orderID, "saga_lease.owner": owner},
+		bson.M{"$unset": bson.M{"saga_lease": ""}},
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao liberar saga do pedido: %w", err)
+	}
+	return nil
+}
+
+func (r *OrderRepository) FindStuckSagas(ctx context.Context, cutoff time.Time, limit int64) ([]models.Order, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"status":          domain.StatusProcessando,
+		"saga.status":     bson.M{"$in": bson.A{domain.SagaRunning, domain.SagaCompensating, domain.SagaCompensated}},
+		"saga.updated_at": bson.M{"$lt": cutoff},
+		"$or": bson.A{
+			bson.M{"saga_lease": bson.M{"$exists": false}},
+			bson.M{"saga_lease.expires_at": bson.M{"$lte": time.Now()}},
+		},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "saga.updated_at", Value: 1}}).SetLimit(limit)
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar sagas paradas: %w", err)
+	}
+
+	var orders []models.Order
+	if err := cursor.All(ctx, &orders); err != nil {
+		return nil, fmt.Errorf("erro ao ler sagas paradas: %w", err)
+	}
+	return orders, nil
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	processedAtIndexName = "processed_at_ttl"
+	// Código retornado pelo MongoDB quando um índice já existe com outras opções.
+	indexOptionsConflictCode = 85
+)
+
+type processedMessage struct {
+	MessageID   string    `bson:"_id"`
+	OrderID     string    `bson:"order_id"`
+	ProcessedAt time.Time `bson:"processed_at"`
+}
+
+type ProcessedMessageRepository struct {
+	collection *mongo.Collection
+}
+
+func NewProcessedMessageRepository(client *mongo.Client, dbName, collectionName string) *ProcessedMessageRepository {
+	return &ProcessedMessageRepository{
+		collection: client.Database(dbName).Collection(collectionName),
+	}
+}
+
+// EnsureIndexes cria o índice TTL que expira os registros antigos. Se o índice
+// já existir com outra retenção, ela é atualizada com collMod.
+func (r *ProcessedMessageRepository) EnsureIndexes(ctx context.Context, ttl time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	expireAfter := int32(ttl.Seconds())
+
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "processed_at", Value: 1}},
+		Options: options.Index().SetName(processedAtIndexName).SetExpireAfterSeconds(expireAfter),
+	})
+
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Code == indexOptionsConflictCode {
+		err = r.collection.Database().RunCommand(ctx, bson.D{
+			{Key: "collMod", Value: r.collection.Name()},
+			{Key: "index", Value: bson.D{
+				{Key: "name", Value: processedAtIndexName},
+				{Key: "expireAfterSeconds", Value: expireAfter},
+			}},
+		}).Err()
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao criar índices de mensagens processadas: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ProcessedMessageRepository) IsProcessed(ctx context.Context, messageID string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	count, err := r.collection.CountDocuments(ctx, bson.M{"_id": messageID}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, fmt.Errorf("erro ao verificar mensagem processada: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+func (r *ProcessedMessageRepository) MarkProcessed(ctx context.Context, messageID, orderID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := r.collection.InsertOne(ctx, processedMessage{
+		MessageID:   messageID,
+		OrderID:     orderID,
+		ProcessedAt: time.Now(),
+	})
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("erro ao registrar mensagem processada: %w", err)
+	}
+
+	return nil
+}
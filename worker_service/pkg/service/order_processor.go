@@ -2,52 +2,413 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"strconv"
+	"sync/atomic"
 	"time"
 
+	"github.com/dev-bruno-arruda/api-pedidos/domain"
 	"github.com/dev-bruno-arruda/api-pedidos/worker_service/pkg/models"
 	"github.com/dev-bruno-arruda/api-pedidos/worker_service/pkg/ports"
 )
 
+// OrderProcessor é idempotente: mensagens já registradas no ProcessedMessageStore
+// são ignoradas, e as transições condicionais de status impedem que uma entrega
+// repetida sem MessageID (ou concorrente) processe o pedido duas vezes.
+//
+// O processamento é uma saga: as etapas rodam em ordem, com o resultado de cada
+// uma e o estado da saga gravados no pedido. Uma nova entrega pula as etapas já
+// concluídas, ou continua as compensações se a saga estava em COMPENSANDO.
+// Falhas permanentes e cancelamentos compensam as etapas concluídas em ordem
+// inversa.
+//
+// Cada execução reserva a saga do pedido por um lease (leaseTTL), renovado a
+// cada etapa, então entregas concorrentes do mesmo pedido (ex.: o processamento
+// e a mensagem de cancelamento) nunca rodam etapas ou compensações ao mesmo tempo.
 type OrderProcessor struct {
-	repo            ports.OrderRepository
-	processingDelay time.Duration
+	repo      ports.OrderRepository
+	processed ports.ProcessedMessageStore
+	steps     []ports.OrderStep
+	actor     string
+	leaseTTL  time.Duration
+	leaseSeq  atomic.Uint64
 }
 
-func NewOrderProcessor(repo ports.OrderRepository, processingDelay time.Duration) *OrderProcessor {
+func NewOrderProcessor(repo ports.OrderRepository, processed ports.ProcessedMessageStore, steps []ports.OrderStep, instanceID string, leaseTTL time.Duration) *OrderProcessor {
 	return &OrderProcessor{
-		repo:            repo,
-		processingDelay: processingDelay,
+		repo:      repo,
+		processed: processed,
+		steps:     steps,
+		actor:     "worker:" + instanceID,
+		leaseTTL:  leaseTTL,
 	}
 }
 
 func (p *OrderProcessor) ProcessOrder(ctx context.Context, message models.OrderMessage) error {
 	log.Printf("Iniciando processamento do pedido: %s", message.OrderID)
 
+	if message.MessageID != "" {
+		processed, err := p.processed.IsProcessed(ctx, message.MessageID)
+		if err != nil {
+			return err
+		}
+		if processed {
+			log.Printf("Mensagem %s do pedido %s já foi processada, ignorando entrega duplicada", message.MessageID, message.OrderID)
+			return nil
+		}
+	}
+
+	owner := p.leaseOwner()
+	if err := p.repo.AcquireLease(ctx, message.OrderID, owner, p.leaseTTL); err != nil {
+		return err
+	}
+	defer p.releaseLease(ctx, message.OrderID, owner)
+
 	order, err := p.repo.FindByOrderID(ctx, message.OrderID)
 	if err != nil {
 		return fmt.Errorf("pedido não encontrado: %w", err)
 	}
 
-	log.Printf("Pedido encontrado: Product=%s, Quantity=%d, Status=%s",
-		order.Product, order.Quantity, order.Status)
+	if message.Status == domain.StatusCancelado {
+		if err := p.compensate(ctx, order, owner); err != nil {
+			return err
+		}
+		p.markProcessed(ctx, message)
+		return nil
+	}
 
-	err = p.repo.UpdateStatus(ctx, message.OrderID, models.StatusProcessando)
-	if err != nil {
-		return fmt.Errorf("erro ao atualizar status para PROCESSANDO: %w", err)
+	log.Printf("Pedido encontrado: Itens=%d, Total=%s %s, Status=%s",
+		len(order.Items), order.Totals.Total, order.Totals.Total.Currency(), order.Status)
+
+	if order.Status == domain.StatusProcessando && isCompensating(order.Saga) {
+		log.Printf("Saga do pedido %s estava em %s, retomando a rejeição", message.OrderID, order.Saga.Status)
+		return p.compensateAndReject(ctx, message, order, owner)
+	}
+
+	if !canProcess(order.Status) {
+		log.Printf("Pedido %s já está em %s, ignorando processamento", message.OrderID, order.Status)
+		p.markProcessed(ctx, message)
+		return nil
+	}
+
+	if order.Status != domain.StatusProcessando {
+		err = p.repo.UpdateStatus(ctx, message.OrderID, p.statusChange(message, order.Status, domain.StatusProcessando, "processamento iniciado"))
+		if err != nil {
+			return p.handleStatusConflict(ctx, message.OrderID, owner, fmt.Errorf("erro ao atualizar status para PROCESSANDO: %w", err))
+		}
+		log.Printf("Status atualizado para PROCESSANDO")
+	}
+
+	if err := p.updateSaga(ctx, order, domain.SagaState{Status: domain.SagaRunning}); err != nil {
+		return err
 	}
-	log.Printf("Status atualizado para PROCESSANDO")
 
-	log.Printf("Processando pedido")
-	time.Sleep(p.processingDelay)
+	for _, step := range p.steps {
+		if order.StepSucceeded(step.Name()) {
+			log.Printf("Etapa %s do pedido %s já concluída, pulando", step.Name(), message.OrderID)
+			continue
+		}
 
-	err = p.repo.UpdateStatus(ctx, message.OrderID, models.StatusProcessado)
+		if err := p.repo.AcquireLease(ctx, message.OrderID, owner, p.leaseTTL); err != nil {
+			return err
+		}
+
+		interrupted, err := p.interrupted(ctx, order, owner, step.Name())
+		if err != nil {
+			return err
+		}
+		if interrupted {
+			p.markProcessed(ctx, message)
+			return nil
+		}
+
+		stepErr := step.Execute(ctx, order)
+		if err := p.recordStep(ctx, message.OrderID, step.Name(), stepErr); err != nil {
+			return err
+		}
+		if stepErr == nil {
+			log.Printf("Etapa %s do pedido %s concluída", step.Name(), message.OrderID)
+			continue
+		}
+
+		if !models.IsPermanent(stepErr) {
+			return fmt.Errorf("etapa %s do pedido %s falhou: %w", step.Name(), message.OrderID, stepErr)
+		}
+
+		log.Printf("Etapa %s do pedido %s falhou de forma permanente, compensando: %v", step.Name(), message.OrderID, stepErr)
+		err = p.updateSaga(ctx, order, domain.SagaState{
+			Status:     domain.SagaCompensating,
+			FailedStep: step.Name(),
+			Error:      stepErr.Error(),
+		})
+		if err != nil {
+			return err
+		}
+		return p.compensateAndReject(ctx, message, order, owner)
+	}
+
+	// A saga é concluída antes do status: se o pedido tiver sido cancelado, o
+	// conflito abaixo a leva para COMPENSADA.
+	if err := p.updateSaga(ctx, order, domain.SagaState{Status: domain.SagaCompleted}); err != nil {
+		return err
+	}
+
+	err = p.repo.UpdateStatus(ctx, message.OrderID, p.statusChange(message, domain.StatusProcessando, domain.StatusProcessado, "processamento concluído"))
 	if err != nil {
-		return fmt.Errorf("erro ao atualizar status para PROCESSADO: %w", err)
+		return p.handleStatusConflict(ctx, message.OrderID, owner, fmt.Errorf("erro ao atualizar status para PROCESSADO: %w", err))
 	}
 
 	log.Printf("Pedido %s processado com sucesso", message.OrderID)
+	p.markProcessed(ctx, message)
+
+	return nil
+}
+
+// RecoverStuckSaga encerra uma saga parada desde antes de cutoff, normalmente
+// porque a mensagem esgotou as tentativas e foi para a DLQ: as etapas
+// concluídas são compensadas e o pedido é rejeitado.
+func (p *OrderProcessor) RecoverStuckSaga(ctx context.Context, orderID string, cutoff time.Time) error {
+	owner := p.leaseOwner()
+	if err := p.repo.AcquireLease(ctx, orderID, owner, p.leaseTTL); err != nil {
+		return err
+	}
+	defer p.releaseLease(ctx, orderID, owner)
 
+	// Relido com o lease: a saga pode ter avançado depois da busca.
+	order, err := p.repo.FindByOrderID(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	if order.Status != domain.StatusProcessando || order.Saga == nil ||
+		order.Saga.Status == domain.SagaCompleted || !order.Saga.UpdatedAt.Before(cutoff) {
+		return nil
+	}
+
+	if !isCompensating(order.Saga) {
+		saga := domain.SagaState{Status: domain.SagaCompensating, Error: "saga abandonada após esgotar as tentativas"}
+		for _, record := range order.Steps {
+			if record.Outcome != domain.StepSucceeded {
+				saga.FailedStep = record.Name
+				saga.Error += ": " + record.Error
+				break
+			}
+		}
+		if err := p.updateSaga(ctx, order, saga); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Saga do pedido %s parada desde %s, compensando", orderID, order.Saga.UpdatedAt.Format(time.RFC3339))
+	return p.compensateAndReject(ctx, models.OrderMessage{OrderID: orderID}, order, owner)
+}
+
+func (p *OrderProcessor) leaseOwner() string {
+	return p.actor + "/" + strconv.FormatUint(p.leaseSeq.Add(1), 10)
+}
+
+// releaseLease roda mesmo com o contexto cancelado no shutdown; se falhar, a
+// reserva expira sozinha após o leaseTTL.
+func (p *OrderProcessor) releaseLease(ctx context.Context, orderID, owner string) {
+	if err := p.repo.ReleaseLease(context.WithoutCancel(ctx), orderID, owner); err != nil {
+		log.Printf("Erro ao liberar saga do pedido %s: %v", orderID, err)
+	}
+}
+
+// interrupted relê o pedido antes de cada etapa: se ele saiu de PROCESSANDO
+// (ex.: cancelado pela API), nenhuma nova etapa é executada e um pedido
+// cancelado tem as etapas já concluídas compensadas.
+func (p *OrderProcessor) interrupted(ctx context.Context, order *models.Order, owner, next string) (bool, error) {
+	current, err := p.repo.FindByOrderID(ctx, order.OrderID)
+	if err != nil {
+		return false, fmt.Errorf("erro ao reler pedido: %w", err)
+	}
+	*order = *current
+
+	if order.Status == domain.StatusProcessando {
+		return false, nil
+	}
+
+	log.Printf("Pedido %s foi alterado para %s durante o processamento, interrompendo antes da etapa %s", order.OrderID, order.Status, next)
+	if order.Status == domain.StatusCancelado {
+		return true, p.compensate(ctx, order, owner)
+	}
+	return true, nil
+}
+
+func (p *OrderProcessor) recordStep(ctx context.Context, orderID, name string, stepErr error) error {
+	record := domain.StepRecord{
+		Name:      name,
+		Outcome:   domain.StepSucceeded,
+		UpdatedAt: time.Now(),
+	}
+	switch {
+	case stepErr == nil:
+	case models.IsPermanent(stepErr):
+		record.Outcome = domain.StepFailedPermanent
+		record.Error = stepErr.Error()
+	default:
+		record.Outcome = domain.StepFailedRetryable
+		record.Error = stepErr.Error()
+	}
+
+	if err := p.repo.RecordStep(ctx, orderID, record); err != nil {
+		return fmt.Errorf("erro ao registrar etapa %s: %w", name, err)
+	}
 	return nil
 }
+
+func (p *OrderProcessor) updateSaga(ctx context.Context, order *models.Order, saga domain.SagaState) error {
+	saga.UpdatedAt = time.Now()
+	if err := p.repo.UpdateSaga(ctx, order.OrderID, saga); err != nil {
+		return err
+	}
+	order.Saga = &saga
+	return nil
+}
+
+// compensateAndReject termina a saga de um pedido com falha permanente e o
+// encerra como REJEITADO. Se uma compensação falhar, o erro devolve a mensagem
+// para a fila e a próxima entrega continua de onde parou.
+func (p *OrderProcessor) compensateAndReject(ctx context.Context, message models.OrderMessage, order *models.Order, owner string) error {
+	if err := p.compensate(ctx, order, owner); err != nil {
+		return err
+	}
+
+	reason := order.Saga.Error
+	if order.Saga.FailedStep != "" {
+		reason = fmt.Sprintf("etapa %s: %s", order.Saga.FailedStep, order.Saga.Error)
+	}
+	err := p.repo.UpdateStatus(ctx, message.OrderID, p.statusChange(message, domain.StatusProcessando, domain.StatusRejeitado, reason))
+	if err != nil {
+		return p.handleStatusConflict(ctx, message.OrderID, owner, fmt.Errorf("erro ao atualizar status para REJEITADO: %w", err))
+	}
+
+	log.Printf("Pedido %s rejeitado: %s", message.OrderID, reason)
+	p.markProcessed(ctx, message)
+	return nil
+}
+
+// compensate desfaz, em ordem inversa, as etapas concluídas e ainda não
+// compensadas, relendo o pedido para ver as etapas gravadas nesta entrega. Como
+// nas etapas, o lease é renovado antes de cada compensação; se ele foi perdido,
+// outro worker assumiu a saga e a compensação para.
+func (p *OrderProcessor) compensate(ctx context.Context, order *models.Order, owner string) error {
+	current, err := p.repo.FindByOrderID(ctx, order.OrderID)
+	if err != nil {
+		return fmt.Errorf("erro ao reler pedido: %w", err)
+	}
+	*order = *current
+
+	if order.Saga == nil {
+		return nil
+	}
+
+	saga := *order.Saga
+	if saga.Status != domain.SagaCompensating {
+		saga.Status = domain.SagaCompensating
+		if err := p.updateSaga(ctx, order, saga); err != nil {
+			return err
+		}
+	}
+
+	for i := len(order.Steps) - 1; i >= 0; i-- {
+		record := order.Steps[i]
+		if !record.NeedsCompensation() {
+			continue
+		}
+
+		step := p.step(record.Name)
+		if step == nil {
+			log.Printf("Etapa %s do pedido %s não está mais registrada, compensação ignorada", record.Name, order.OrderID)
+			continue
+		}
+
+		if err := p.repo.AcquireLease(ctx, order.OrderID, owner, p.leaseTTL); err != nil {
+			return err
+		}
+
+		stepErr := step.Compensate(ctx, order)
+		outcome, errMsg := domain.StepSucceeded, ""
+		if stepErr != nil {
+			outcome, errMsg = domain.StepFailedRetryable, stepErr.Error()
+		}
+		if err := p.repo.RecordCompensation(ctx, order.OrderID, record.Name, outcome, errMsg); err != nil {
+			return err
+		}
+		if stepErr != nil {
+			return fmt.Errorf("compensação da etapa %s do pedido %s falhou: %w", record.Name, order.OrderID, stepErr)
+		}
+		log.Printf("Etapa %s do pedido %s compensada", record.Name, order.OrderID)
+	}
+
+	saga.Status = domain.SagaCompensated
+	return p.updateSaga(ctx, order, saga)
+}
+
+func (p *OrderProcessor) step(name string) ports.OrderStep {
+	for _, step := range p.steps {
+		if step.Name() == name {
+			return step
+		}
+	}
+	return nil
+}
+
+// markProcessed não propaga erros: se o registro falhar, uma nova entrega ainda
+// é barrada pelo status do pedido.
+func (p *OrderProcessor) markProcessed(ctx context.Context, message models.OrderMessage) {
+	if message.MessageID == "" {
+		return
+	}
+	if err := p.processed.MarkProcessed(ctx, message.MessageID, message.OrderID); err != nil {
+		log.Printf("Erro ao registrar mensagem %s como processada: %v", message.MessageID, err)
+	}
+}
+
+func (p *OrderProcessor) statusChange(message models.OrderMessage, from, to domain.Status, reason string) domain.StatusChange {
+	return domain.StatusChange{
+		From:       from,
+		To:         to,
+		Actor:      p.actor,
+		Reason:     reason,
+		DeliveryID: message.MessageID,
+	}
+}
+
+// handleStatusConflict trata o caso em que o status mudou durante o processamento
+// (ex.: pedido cancelado pela API). Se o pedido não puder mais ser processado o
+// processamento é encerrado sem erro, evitando que a mensagem volte para a fila.
+func (p *OrderProcessor) handleStatusConflict(ctx context.Context, orderID, owner string, err error) error {
+	if !errors.Is(err, domain.ErrInvalidTransition) {
+		return err
+	}
+
+	order, findErr := p.repo.FindByOrderID(ctx, orderID)
+	if findErr != nil {
+		return fmt.Errorf("%w (erro ao reler pedido: %v)", err, findErr)
+	}
+
+	if !canProcess(order.Status) {
+		log.Printf("Pedido %s foi alterado para %s durante o processamento, interrompendo", orderID, order.Status)
+		// A mensagem de cancelamento pode ter sido tratada antes das etapas
+		// desta entrega; as compensações já feitas não se repetem.
+		if order.Status == domain.StatusCancelado {
+			return p.compensate(ctx, order, owner)
+		}
+		return nil
+	}
+
+	return err
+}
+
+// isCompensating indica uma falha permanente cujo pedido ainda não foi
+// rejeitado, inclusive quando o worker caiu logo após terminar as compensações.
+func isCompensating(saga *domain.SagaState) bool {
+	return saga != nil && (saga.Status == domain.SagaCompensating || saga.Status == domain.SagaCompensated)
+}
+
+func canProcess(status domain.Status) bool {
+	return status == domain.StatusProcessando || status.CanTransitionTo(domain.StatusProcessando)
+}
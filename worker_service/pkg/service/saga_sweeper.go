@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/dev-bruno-arruda/api-pedidos/worker_service/pkg/models"
+	"github.com/dev-bruno-arruda/api-pedidos/worker_service/pkg/ports"
+)
+
+const sagaSweepBatchSize = 100
+
+type SagaSweeperConfig struct {
+	Interval   time.Duration
+	StuckAfter time.Duration
+}
+
+// SagaSweeper encerra as sagas que ficaram paradas em PROCESSANDO, como as de
+// mensagens que esgotaram as tentativas e foram para a DLQ. Sem ele o pedido
+// ficaria com estoque reservado e pagamento autorizado até um replay manual.
+type SagaSweeper struct {
+	repo      ports.OrderRepository
+	processor *OrderProcessor
+	config    SagaSweeperConfig
+	stop      chan struct{}
+	wg        sync.WaitGroup
+}
+
+func NewSagaSweeper(repo ports.OrderRepository, processor *OrderProcessor, config SagaSweeperConfig) *SagaSweeper {
+	return &SagaSweeper{
+		repo:      repo,
+		processor: processor,
+		config:    config,
+		stop:      make(chan struct{}),
+	}
+}
+
+func (s *SagaSweeper) Start() {
+	s.wg.Add(1)
+	go s.run()
+}
+
+func (s *SagaSweeper) Shutdown() {
+	close(s.stop)
+	s.wg.Wait()
+	log.Println("Varredura de sagas paradas encerrada")
+}
+
+func (s *SagaSweeper) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.sweep()
+		}
+	}
+}
+
+func (s *SagaSweeper) sweep() {
+	ctx := context.Background()
+	cutoff := time.Now().Add(-s.config.StuckAfter)
+
+	orders, err := s.repo.FindStuckSagas(ctx, cutoff, sagaSweepBatchSize)
+	if err != nil {
+		log.Printf("Erro ao buscar sagas paradas: %v", err)
+		return
+	}
+
+	for _, order := range orders {
+		select {
+		case <-s.stop:
+			return
+		default:
+		}
+
+		err := s.processor.RecoverStuckSaga(ctx, order.OrderID, cutoff)
+		if errors.Is(err, models.ErrSagaLeaseHeld) {
+			continue
+		}
+		if err != nil {
+			log.Printf("Erro ao encerrar saga parada do pedido %s: %v", order.OrderID, err)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/dev-bruno-arruda/api-pedidos/domain"
+	"github.com/dev-bruno-arruda/api-pedidos/worker_service/pkg/models"
+	"github.com/dev-bruno-arruda/api-pedidos/worker_service/pkg/ports"
+)
+
+const (
+	StepValidate      = "validate"
+	StepReserveStock  = "reserve_stock"
+	StepChargePayment = "charge_payment"
+	StepNotify        = "notify"
+)
+
+var errInvalidOrder = errors.New("pedido inválido")
+
+type validateStep struct{}
+
+func NewValidateStep() ports.OrderStep {
+	return validateStep{}
+}
+
+func (validateStep) Name() string { return StepValidate }
+
+func (validateStep) Execute(ctx context.Context, order *models.Order) error {
+	if len(order.Items) == 0 {
+		return models.Permanent(fmt.Errorf("%w: pedido sem itens", errInvalidOrder))
+	}
+	for i, item := range order.Items {
+		if item.Quantity <= 0 {
+			return models.Permanent(fmt.Errorf("%w: item %d com quantidade %d", errInvalidOrder, i, item.Quantity))
+		}
+	}
+	if order.Totals.Total.IsNegative() {
+		return models.Permanent(fmt.Errorf("%w: total negativo", errInvalidOrder))
+	}
+	return nil
+}
+
+func (validateStep) Compensate(ctx context.Context, order *models.Order) error { return nil }
+
+type reserveStockStep struct {
+	inventory ports.InventoryRepository
+}
+
+func NewReserveStockStep(inventory ports.InventoryRepository) ports.OrderStep {
+	return reserveStockStep{inventory: inventory}
+}
+
+func (reserveStockStep) Name() string { return StepReserveStock }
+
+func (s reserveStockStep) Execute(ctx context.Context, order *models.Order) error {
+	err := s.inventory.Reserve(ctx, order.OrderID, reservationItems(order.Items))
+	if err != nil {
+		var stockErr *models.StockError
+		if errors.As(err, &stockErr) {
+			return models.Permanent(err)
+		}
+		return fmt.Errorf("erro ao reservar estoque: %w", err)
+	}
+	log.Printf("Estoque reservado para o pedido %s", order.OrderID)
+	return nil
+}
+
+func (s reserveStockStep) Compensate(ctx context.Context, order *models.Order) error {
+	released, err := s.inventory.Release(ctx, order.OrderID)
+	if err != nil {
+		return fmt.Errorf("erro ao liberar estoque: %w", err)
+	}
+	if released {
+		log.Printf("Estoque do pedido %s liberado", order.OrderID)
+	}
+	return nil
+}
+
+// chargePaymentStep autoriza e captura o total do pedido no gateway, gravando
+// cada transação no pedido para que uma nova entrega continue da última feita.
+// Pedidos antigos, sem preço, não são cobrados.
+type chargePaymentStep struct {
+	gateway ports.PaymentGateway
+	repo    ports.OrderRepository
+	timeout time.Duration
+}
+
+func NewChargePaymentStep(gateway ports.PaymentGateway, repo ports.OrderRepository, timeout time.Duration) ports.OrderStep {
+	return chargePaymentStep{gateway: gateway, repo: repo, timeout: timeout}
+}
+
+func (chargePaymentStep) Name() string { return StepChargePayment }
+
+func (s chargePaymentStep) Execute(ctx context.Context, order *models.Order) error {
+	total := order.Totals.Total
+	if total.IsZero() {
+		log.Printf("Pedido %s sem valor a cobrar", order.OrderID)
+		return nil
+	}
+
+	payment := domain.Payment{Amount: total}
+	if order.Payment != nil {
+		payment = *order.Payment
+	}
+
+	if payment.Status == "" {
+		log.Printf("Autorizando pagamento do pedido %s: %s %s", order.OrderID, total, total.Currency())
+
+		authorizationID, err := s.call(ctx, func(ctx context.Context) (string, error) {
+			return s.gateway.Authorize(ctx, order.OrderID, payment.Amount)
+		})
+		if errors.Is(err, models.ErrPaymentDeclined) {
+			payment.Status = domain.PaymentDeclined
+			payment.DeclineReason = err.Error()
+			if saveErr := s.save(ctx, order, payment); saveErr != nil {
+				return saveErr
+			}
+			return models.Permanent(err)
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao autorizar pagamento: %w", err)
+		}
+
+		payment.Status = domain.PaymentAuthorized
+		payment.AuthorizationID = authorizationID
+		if err := s.save(ctx, order, payment); err != nil {
+			return err
+		}
+	}
+
+	if payment.Status == domain.PaymentAuthorized {
+		captureID, err := s.call(ctx, func(ctx context.Context) (string, error) {
+			return s.gateway.Capture(ctx, payment.AuthorizationID, payment.Amount)
+		})
+		if errors.Is(err, models.ErrPaymentDeclined) {
+			// A autorização continua válida até ser cancelada na compensação.
+			payment.DeclineReason = err.Error()
+			if saveErr := s.save(ctx, order, payment); saveErr != nil {
+				return saveErr
+			}
+			return models.Permanent(fmt.Errorf("erro ao capturar pagamento: %w", err))
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao capturar pagamento: %w", err)
+		}
+
+		payment.Status = domain.PaymentCaptured
+		payment.CaptureID = captureID
+		if err := s.save(ctx, order, payment); err != nil {
+			return err
+		}
+		log.Printf("Pagamento do pedido %s capturado: %s", order.OrderID, captureID)
+	}
+
+	return nil
+}
+
+// Compensate estorna o valor capturado ou cancela a autorização que não chegou
+// a ser capturada (captura recusada, falha ou pedido cancelado no meio).
+func (s chargePaymentStep) Compensate(ctx context.Context, order *models.Order) error {
+	if order.Payment == nil {
+		return nil
+	}
+
+	switch order.Payment.Status {
+	case domain.PaymentCaptured:
+		return s.refund(ctx, order)
+	case domain.PaymentAuthorized:
+		return s.void(ctx, order)
+	}
+	return nil
+}
+
+func (s chargePaymentStep) void(ctx context.Context, order *models.Order) error {
+	payment := *order.Payment
+	voidID, err := s.call(ctx, func(ctx context.Context) (string, error) {
+		return s.gateway.Void(ctx, payment.AuthorizationID, payment.Amount)
+	})
+	if err != nil {
+		return fmt.Errorf("erro ao cancelar autorização do pagamento: %w", err)
+	}
+
+	payment.Status = domain.PaymentVoided
+	payment.VoidID = voidID
+	if err := s.save(ctx, order, payment); err != nil {
+		return err
+	}
+	log.Printf("Autorização do pagamento do pedido %s cancelada: %s", order.OrderID, voidID)
+	return nil
+}
+
+func (s chargePaymentStep) refund(ctx context.Context, order *models.Order) error {
+	payment := *order.Payment
+	refundID, err := s.call(ctx, func(ctx context.Context) (string, error) {
+		return s.gateway.Refund(ctx, payment.CaptureID, payment.Amount)
+	})
+	if err != nil {
+		return fmt.Errorf("erro ao estornar pagamento: %w", err)
+	}
+
+	payment.Status = domain.PaymentRefunded
+	payment.RefundID = refundID
+	if err := s.save(ctx, order, payment); err != nil {
+		return err
+	}
+	log.Printf("Pagamento do pedido %s estornado: %s", order.OrderID, refundID)
+	return nil
+}
+
+func (s chargePaymentStep) call(ctx context.Context, fn func(ctx context.Context) (string, error)) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return fn(ctx)
+}
+
+func (s chargePaymentStep) save(ctx context.Context, order *models.Order, payment domain.Payment) error {
+	payment.UpdatedAt = time.Now()
+	if err := s.repo.UpdatePayment(ctx, order.OrderID, payment); err != nil {
+		return err
+	}
+	order.Payment = &payment
+	return nil
+}
+
+// notifyStep avisa o cliente do pedido. A notificação ainda é apenas um log;
+// pedidos sem cliente (anteriores ao cadastro) ou com cliente removido são
+// notificados de forma genérica.
+type notifyStep struct {
+	customers ports.CustomerRepository
+}
+
+func NewNotifyStep(customers ports.CustomerRepository) ports.OrderStep {
+	return notifyStep{customers: customers}
+}
+
+func (notifyStep) Name() string { return StepNotify }
+
+func (s notifyStep) Execute(ctx context.Context, order *models.Order) error {
+	recipient, err := s.recipient(ctx, order)
+	if err != nil {
+		return err
+	}
+	log.Printf("Notificação enviada para %s: pedido %s confirmado", recipient, order.OrderID)
+	return nil
+}
+
+func (s notifyStep) Compensate(ctx context.Context, order *models.Order) error {
+	recipient, err := s.recipient(ctx, order)
+	if err != nil {
+		return err
+	}
+	log.Printf("Notificação enviada para %s: pedido %s não será atendido", recipient, order.OrderID)
+	return nil
+}
+
+func (s notifyStep) recipient(ctx context.Context, order *models.Order) (string, error) {
+	if order.CustomerID == "" {
+		return "cliente não identificado", nil
+	}
+
+	customer, err := s.customers.FindByCustomerID(ctx, order.CustomerID)
+	if err != nil {
+		if errors.Is(err, models.ErrCustomerNotFound) {
+			log.Printf("Cliente %s do pedido %s não encontrado", order.CustomerID, order.OrderID)
+			return "cliente não identificado", nil
+		}
+		return "", err
+	}
+	return fmt.Sprintf("%s <%s>", customer.Name, customer.Email), nil
+}
+
+// reservationItems agrupa as quantidades por SKU. Itens de pedidos antigos, sem
+// SKU, não controlam estoque.
+func reservationItems(items []domain.OrderItem) []models.ReservationItem {
+	var reservation []models.ReservationItem
+	index := make(map[string]int)
+	for _, item := range items {
+		if item.SKU == "" {
+			continue
+		}
+		if i, ok := index[item.SKU]; ok {
+			reservation[i].Quantity += item.Quantity
+			continue
+		}
+		index[item.SKU] = len(reservation)
+		reservation = append(reservation, models.ReservationItem{SKU: item.SKU, Quantity: item.Quantity})
+	}
+	return reservation
+}